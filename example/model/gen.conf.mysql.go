@@ -22,6 +22,7 @@ type MySQLConfig struct {
 	Database        string
 	PoolSize        int
 	ConnMaxLifeTime time.Duration
+	ConnectAttrs    map[string]string
 }
 
 func MySQLSetup(cf *MySQLConfig) {
@@ -31,12 +32,15 @@ func MySQLSetup(cf *MySQLConfig) {
 func MySQL() *orm.DBStore {
 	var err error
 	_mysql_once.Do(func() {
-		_mysql_store, err = orm.NewDBStore("mysql",
-			_mysql_cfg.Host,
-			_mysql_cfg.Port,
-			_mysql_cfg.Database,
-			_mysql_cfg.UserName,
-			_mysql_cfg.Password)
+		_mysql_store, err = orm.NewDBStoreConfig(&orm.DBConfig{
+			Driver:       "mysql",
+			Host:         _mysql_cfg.Host,
+			Port:         _mysql_cfg.Port,
+			Database:     _mysql_cfg.Database,
+			UserName:     _mysql_cfg.UserName,
+			Password:     _mysql_cfg.Password,
+			ConnectAttrs: _mysql_cfg.ConnectAttrs,
+		})
 		if err != nil {
 			panic(err)
 		}
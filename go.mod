@@ -1,16 +1,21 @@
 module git.yixindev.net/yeetalk/db-orm
 
-go 1.12
+go 1.18
 
 require (
-	cloud.google.com/go v0.34.0 // indirect
 	github.com/auto-program/db-orm v0.0.0-20190225103723-d9695925dbbf
-	github.com/denisenkom/go-mssqldb v0.0.0-20190204142019-df6d76eb9289 // indirect
-	github.com/emirpasic/gods v1.9.0 // indirect
-	github.com/go-sql-driver/mysql v1.4.1 // indirect
-	github.com/opentracing/opentracing-go v1.0.2 // indirect
+	github.com/denisenkom/go-mssqldb v0.0.0-20190204142019-df6d76eb9289
+	github.com/emirpasic/gods v1.9.0
+	github.com/go-sql-driver/mysql v1.8.1
+	github.com/opentracing/opentracing-go v1.0.2
+	gopkg.in/redis.v5 v5.2.9
+)
+
+require (
+	cloud.google.com/go v0.34.0 // indirect
+	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/spf13/cobra v0.0.3 // indirect
 	github.com/spf13/viper v1.3.1 // indirect
+	golang.org/x/crypto v0.0.0-20181203042331-505ab145d0a9 // indirect
 	golang.org/x/net v0.0.0-20190301231341-16b79f2e4e95 // indirect
-	gopkg.in/redis.v5 v5.2.9 // indirect
 )
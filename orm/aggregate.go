@@ -0,0 +1,55 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// aggregate scans SELECT agg(column) FROM table WHERE conditions into dest.
+// table and column are written as given, so column may be an expression.
+func (store *DBStore) aggregate(dest interface{}, agg, table, column string, conditions []string, args []interface{}) error {
+	query := fmt.Sprintf("SELECT %s(%s) FROM %s %s", agg, column, table, SQLWhere(conditions))
+	rows, err := store.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		if err := rows.Scan(dest); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Sum is not Valid when no row matches.
+func (store *DBStore) Sum(table, column string, conditions []string, args ...interface{}) (sql.NullFloat64, error) {
+	var sum sql.NullFloat64
+	err := store.aggregate(&sum, "SUM", table, column, conditions, args)
+	return sum, err
+}
+
+// SumInt64 is Sum for integer columns, exact beyond float64 precision.
+func (store *DBStore) SumInt64(table, column string, conditions []string, args ...interface{}) (sql.NullInt64, error) {
+	var sum sql.NullInt64
+	err := store.aggregate(&sum, "SUM", table, column, conditions, args)
+	return sum, err
+}
+
+// Avg is not Valid when no row matches.
+func (store *DBStore) Avg(table, column string, conditions []string, args ...interface{}) (sql.NullFloat64, error) {
+	var avg sql.NullFloat64
+	err := store.aggregate(&avg, "AVG", table, column, conditions, args)
+	return avg, err
+}
+
+// Max scans the largest value of column into dest, which should be a
+// sql.Null type (or a pointer pointer) for when no row matches.
+func (store *DBStore) Max(dest interface{}, table, column string, conditions []string, args ...interface{}) error {
+	return store.aggregate(dest, "MAX", table, column, conditions, args)
+}
+
+// Min scans the smallest value of column into dest like Max.
+func (store *DBStore) Min(dest interface{}, table, column string, conditions []string, args ...interface{}) error {
+	return store.aggregate(dest, "MIN", table, column, conditions, args)
+}
@@ -0,0 +1,124 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+)
+
+// CheckArgs toggles a pre-flight check that the number of ? placeholders
+// matches the number of args. The count skips literals and comments but is
+// not a full SQL parser, hence it is off by default.
+func (store *DBStore) CheckArgs(b bool) {
+	store.checkArgs = b
+}
+
+// CheckArgTypes toggles a pre-flight check that every arg is a type
+// database/sql can convert or implements driver.Valuer, naming the
+// offending arg instead of failing deep inside the driver.
+func (store *DBStore) CheckArgTypes(b bool) {
+	store.checkArgTypes = b
+}
+
+// SetMaxArgs caps the args of a statement, failing larger ones before they
+// reach the server with an error that points to chunking. The bulk helpers
+// split their statements to stay within it. n <= 0 restores the default,
+// the limit of the driver's protocol.
+func (store *DBStore) SetMaxArgs(n int) {
+	store.maxArgs = n
+}
+
+func (o *options) argLimit() int {
+	if o.maxArgs > 0 {
+		return o.maxArgs
+	}
+	return maxPlaceholders(o.driver)
+}
+
+// SetFaultInjector is for tests only: fn is called with every statement
+// before it is sent and a non-nil error fails the statement with it, so
+// that failure paths can be exercised without breaking a real database. fn
+// may also sleep to simulate latency. Call it during setup; nil removes it.
+func (store *DBStore) SetFaultInjector(fn func(sql string) error) {
+	store.fault = fn
+}
+
+// SetQueryRewriter makes every statement run as fn returns it, e.g. to
+// move to a renamed table during a migration without touching the call
+// sites. The debug log shows the statement before and after rewriting.
+// Call it during setup; nil, the default, runs statements unchanged.
+func (store *DBStore) SetQueryRewriter(fn func(sql string) string) {
+	store.rewrite = fn
+}
+
+// prepare converts the placeholders of query for the driver and validates
+// args against the ? form of the statement.
+func (o *options) prepare(query string, args []interface{}) (string, error) {
+	if o.rewrite != nil {
+		if o.debug {
+			log.Println("DEBUG: ", "rewriting", query)
+		}
+		query = o.rewrite(query)
+	}
+	if o.fault != nil {
+		if err := o.fault(query); err != nil {
+			return "", err
+		}
+	}
+	converted, err := convertPlaceholders(o.driver, query)
+	if err != nil {
+		return "", err
+	}
+	checked := converted
+	if o.driver == "mssql" {
+		checked = query
+	}
+	return converted, o.validate(checked, args)
+}
+
+func (o *options) validate(query string, args []interface{}) error {
+	if limit := o.argLimit(); len(args) > limit {
+		return fmt.Errorf("statement has %d args, more than the limit of %d; split it into smaller chunks", len(args), limit)
+	}
+	if o.checkArgTypes {
+		if err := validateArgTypes(args); err != nil {
+			return err
+		}
+	}
+	if !o.checkArgs {
+		return nil
+	}
+	named := 0
+	for _, arg := range args {
+		if _, ok := arg.(sql.NamedArg); ok {
+			named++
+		}
+	}
+	n := countPlaceholders(query)
+	if named > 0 {
+		if n > 0 {
+			return fmt.Errorf("statement mixes %d ? placeholders with %d named args", n, named)
+		}
+		return nil
+	}
+	if n != len(args) {
+		return fmt.Errorf("statement has %d placeholders but %d args", n, len(args))
+	}
+	return nil
+}
+
+func validateArgTypes(args []interface{}) error {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case sql.NamedArg:
+			arg = v.Value
+		case sql.Out:
+			continue
+		}
+		if _, err := driver.DefaultParameterConverter.ConvertValue(arg); err != nil {
+			return fmt.Errorf("arg %d: unsupported type %T (consider implementing driver.Valuer): %v", i, arg, err)
+		}
+	}
+	return nil
+}
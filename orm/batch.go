@@ -0,0 +1,84 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrBatchWriterClosed = errors.New("batch writer closed")
+
+// BatchWriter buffers rows for a table and inserts them with BulkInsert
+// once batchSize rows are pending. It is safe for concurrent use.
+type BatchWriter struct {
+	store     *DBStore
+	table     string
+	cols      []string
+	batchSize int
+
+	mu     sync.Mutex
+	rows   [][]interface{}
+	closed bool
+}
+
+// NewBatchWriter returns a BatchWriter inserting into cols of table. The
+// writer must be closed to insert the last rows.
+func (store *DBStore) NewBatchWriter(table string, cols []string, batchSize int) *BatchWriter {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &BatchWriter{
+		store:     store,
+		table:     table,
+		cols:      cols,
+		batchSize: batchSize,
+		rows:      make([][]interface{}, 0, batchSize),
+	}
+}
+
+// Add buffers a row, flushing if the batch is full, in which case it
+// returns the error of the flush.
+func (w *BatchWriter) Add(values ...interface{}) error {
+	if len(values) != len(w.cols) {
+		return fmt.Errorf("row has %d values for %d columns", len(values), len(w.cols))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrBatchWriterClosed
+	}
+	w.rows = append(w.rows, append([]interface{}{}, values...))
+	if len(w.rows) < w.batchSize {
+		return nil
+	}
+	return w.flush()
+}
+
+// Flush inserts the pending rows. Rows of a failed flush are dropped, as
+// part of them may have been inserted.
+func (w *BatchWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+func (w *BatchWriter) flush() error {
+	if len(w.rows) == 0 {
+		return nil
+	}
+	_, err := w.store.BulkInsert(w.table, w.cols, w.rows)
+	w.rows = w.rows[:0]
+	return err
+}
+
+// Close flushes the pending rows; later Adds fail with
+// ErrBatchWriterClosed.
+func (w *BatchWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.flush()
+}
@@ -0,0 +1,204 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// bulkResult sums up the results of a statement split into chunks.
+type bulkResult struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r *bulkResult) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+
+func (r *bulkResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+func (r *bulkResult) add(res sql.Result) {
+	if id, err := res.LastInsertId(); err == nil {
+		r.lastInsertId = id
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		r.rowsAffected += n
+	}
+}
+
+// BulkUpdate sets different values per row in a single statement of the
+// form UPDATE table SET col = CASE keyCol WHEN ? THEN ? ... END WHERE keyCol
+// IN (...). updates maps a key to the columns to set for it. The statement
+// is split into chunks that stay within the driver's placeholder limit;
+// chunks are not atomic unless the store is used inside a transaction.
+func (store *DBStore) BulkUpdate(table, keyCol string, updates map[interface{}]map[string]interface{}) (sql.Result, error) {
+	result := &bulkResult{}
+	if err := checkIdents(table, keyCol); err != nil {
+		return result, err
+	}
+	keys := make([]interface{}, 0, len(updates))
+	for key, cols := range updates {
+		if err := checkColumns(table, cols); err != nil {
+			return result, err
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+
+	limit := store.argLimit()
+	for start := 0; start < len(keys); {
+		end, n := start, 0
+		for end < len(keys) {
+			cost := 1 + 2*len(updates[keys[end]])
+			if end > start && n+cost > limit {
+				break
+			}
+			n += cost
+			end++
+		}
+		query, args := bulkUpdateSQL(store.driver, table, keyCol, keys[start:end], updates)
+		if query != "" {
+			res, err := store.Exec(query, args...)
+			if err != nil {
+				return result, err
+			}
+			result.add(res)
+		}
+		start = end
+	}
+	return result, nil
+}
+
+func bulkUpdateSQL(driver, table, keyCol string, keys []interface{}, updates map[interface{}]map[string]interface{}) (string, []interface{}) {
+	columns := []string{}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		for col := range updates[key] {
+			if !seen[col] {
+				seen[col] = true
+				columns = append(columns, col)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return "", nil
+	}
+	sort.Strings(columns)
+
+	quotedKey := quoteIdent(driver, keyCol)
+	args := []interface{}{}
+	sets := make([]string, 0, len(columns))
+	for _, col := range columns {
+		quoted := quoteIdent(driver, col)
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s = CASE %s", quoted, quotedKey)
+		for _, key := range keys {
+			if v, ok := updates[key][col]; ok {
+				b.WriteString(" WHEN ? THEN ?")
+				args = append(args, key, v)
+			}
+		}
+		fmt.Fprintf(&b, " ELSE %s END", quoted)
+		sets = append(sets, b.String())
+	}
+	args = append(args, keys...)
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s IN (%s)",
+		quoteIdent(driver, table),
+		strings.Join(sets, ", "),
+		quotedKey,
+		strings.Join(NewStringSlice(len(keys), "?"), ",")), args
+}
+
+// mssqlMaxValuesRows is the row limit of an mssql INSERT ... VALUES list.
+const mssqlMaxValuesRows = 1000
+
+// BulkInsert inserts rows with multi-row INSERT statements, split into
+// chunks that stay within the driver's limits. Chunks are not atomic
+// unless the store is used inside a transaction.
+func (store *DBStore) BulkInsert(table string, columns []string, rows [][]interface{}) (sql.Result, error) {
+	return store.BulkInsertProgress(table, columns, rows, nil)
+}
+
+// BulkInsertProgress is BulkInsert calling progress, if not nil, after each
+// chunk with its index, row count and rows affected.
+func (store *DBStore) BulkInsertProgress(table string, columns []string, rows [][]interface{}, progress func(chunkIndex, rowsInChunk int, affected int64)) (sql.Result, error) {
+	return store.bulkInsert(table, columns, rows, "", progress)
+}
+
+// BulkUpsert is BulkInsert with ON DUPLICATE KEY UPDATE setting the update
+// columns, or every column if there are none, of rows that collide with
+// an existing unique key to the inserted values. Only mysql is supported.
+// rowsAffected counts 1 per inserted and 2 per updated row.
+func (store *DBStore) BulkUpsert(table string, columns []string, rows [][]interface{}, update []string) (sql.Result, error) {
+	if store.driver != "mysql" {
+		return &bulkResult{}, fmt.Errorf("unsupport db driver: %s", store.driver)
+	}
+	if len(update) == 0 {
+		update = columns
+	}
+	if err := checkIdents(update...); err != nil {
+		return &bulkResult{}, err
+	}
+	sets := make([]string, len(update))
+	for i, col := range update {
+		quoted := quoteIdent(store.driver, col)
+		sets[i] = quoted + " = VALUES(" + quoted + ")"
+	}
+	return store.bulkInsert(table, columns, rows, " ON DUPLICATE KEY UPDATE "+strings.Join(sets, ", "), nil)
+}
+
+func (store *DBStore) bulkInsert(table string, columns []string, rows [][]interface{}, suffix string, progress func(chunkIndex, rowsInChunk int, affected int64)) (sql.Result, error) {
+	result := &bulkResult{}
+	if len(columns) == 0 {
+		return result, fmt.Errorf("no columns to insert into %s", table)
+	}
+	if err := checkIdents(append([]string{table}, columns...)...); err != nil {
+		return result, err
+	}
+	size := store.argLimit() / len(columns)
+	if size < 1 {
+		return result, fmt.Errorf("%d columns exceed the limit of %d args per statement", len(columns), store.argLimit())
+	}
+	if store.driver == "mssql" && size > mssqlMaxValuesRows {
+		size = mssqlMaxValuesRows
+	}
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdent(store.driver, col)
+	}
+	group := "(" + strings.Join(NewStringSlice(len(columns), "?"), ",") + ")"
+	for chunk, start := 0, 0; start < len(rows); chunk, start = chunk+1, start+size {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+		args := make([]interface{}, 0, (end-start)*len(columns))
+		for _, row := range rows[start:end] {
+			if len(row) != len(columns) {
+				return result, fmt.Errorf("row has %d values for %d columns", len(row), len(columns))
+			}
+			args = append(args, row...)
+		}
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s%s",
+			quoteIdent(store.driver, table),
+			strings.Join(quoted, ", "),
+			strings.Join(NewStringSlice(end-start, group), ","),
+			suffix)
+		res, err := store.Exec(query, args...)
+		if err != nil {
+			return result, err
+		}
+		result.add(res)
+		if progress != nil {
+			affected, _ := res.RowsAffected()
+			progress(chunk, end-start, affected)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,141 @@
+package orm
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// queryCacheSize bounds the entries of a query cache; expired entries are
+// swept, then arbitrary ones evicted, when it is reached.
+const queryCacheSize = 1024
+
+// queryCache is the QueryCached cache. A nil queryCache, as on stores not
+// built by NewDBStoreConfig, caches nothing.
+type queryCache struct {
+	mu      sync.Mutex
+	entries map[string]queryCacheEntry
+}
+
+type queryCacheEntry struct {
+	rows    []map[string]interface{}
+	expires time.Time
+}
+
+func newQueryCache() *queryCache {
+	return &queryCache{entries: map[string]queryCacheEntry{}}
+}
+
+func (c *queryCache) get(key string) ([]map[string]interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return copyRowMaps(entry.rows), true
+}
+
+func (c *queryCache) set(key string, rows []map[string]interface{}, ttl time.Duration) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= queryCacheSize {
+		c.evict()
+	}
+	c.entries[key] = queryCacheEntry{rows: copyRowMaps(rows), expires: time.Now().Add(ttl)}
+}
+
+// evict makes room for an entry, dropping expired entries or, if there are
+// none, an arbitrary one. c.mu must be held.
+func (c *queryCache) evict() {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	for key := range c.entries {
+		if len(c.entries) < queryCacheSize {
+			break
+		}
+		delete(c.entries, key)
+	}
+}
+
+func (c *queryCache) delete(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+func (c *queryCache) reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]queryCacheEntry{}
+}
+
+func copyRowMaps(rows []map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		m := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			if b, ok := v.([]byte); ok {
+				v = append([]byte(nil), b...)
+			}
+			m[k] = v
+		}
+		out[i] = m
+	}
+	return out
+}
+
+// CacheKey is the key QueryCached stores the result of sql and args under.
+func CacheKey(sql string, args ...interface{}) string {
+	return fmt.Sprintf("%s %#v", sql, args)
+}
+
+// QueryCached is Query reading all rows into maps, served from an in-memory
+// cache for ttl after the first read. Callers get their own copy of the
+// rows. The cache holds at most 1024 results; stores not built by
+// NewDBStoreConfig do not cache.
+func (store *DBStore) QueryCached(ttl time.Duration, sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	key := CacheKey(sql, args...)
+	if rows, ok := store.cache.get(key); ok {
+		return rows, nil
+	}
+	rows, err := store.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := rowsToMaps(rows, store.rowLimit(store.context()))
+	if err != nil {
+		return nil, err
+	}
+	store.cache.set(key, result, ttl)
+	return result, nil
+}
+
+// InvalidateCache drops the cached result of key, see CacheKey.
+func (store *DBStore) InvalidateCache(key string) {
+	store.cache.delete(key)
+}
+
+func (store *DBStore) InvalidateCacheAll() {
+	store.cache.reset()
+}
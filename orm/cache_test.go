@@ -0,0 +1,31 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestQueryCacheSize(t *testing.T) {
+	c := newQueryCache()
+	c.set("expired", nil, -time.Second)
+	for i := 0; i < queryCacheSize+10; i++ {
+		c.set(fmt.Sprint(i), nil, time.Minute)
+	}
+	if n := len(c.entries); n != queryCacheSize {
+		t.Errorf("expected %d entries, got %d", queryCacheSize, n)
+	}
+	if _, ok := c.entries["expired"]; ok {
+		t.Errorf("expected expired entry to be evicted")
+	}
+}
+
+func TestQueryCachedWithoutCache(t *testing.T) {
+	store := fakeStore("SELECT names", []string{"name"}, []driver.Value{"a"})
+	rows, err := store.QueryCached(time.Minute, "SELECT names")
+	if err != nil || len(rows) != 1 || rows[0]["name"] != "a" {
+		t.Errorf("expected [a], got %v (%v)", rows, err)
+	}
+	store.InvalidateCacheAll()
+}
@@ -0,0 +1,81 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClusterStore splits reads from writes: Query is routed round robin to the
+// replicas while Exec and transactions go to the primary.
+type ClusterStore struct {
+	primary  *DBStore
+	replicas []*DBStore
+	next     uint32
+
+	lagMu   sync.RWMutex
+	maxLag  time.Duration
+	lags    []time.Duration
+	lagging []bool
+	stopLag chan struct{}
+}
+
+// NewClusterStore routes reads to replicas, or to primary if there is none.
+func NewClusterStore(primary *DBStore, replicas ...*DBStore) *ClusterStore {
+	return &ClusterStore{primary: primary, replicas: replicas}
+}
+
+// Primary pins a logical operation to the primary, e.g. for reads that
+// must see its own writes.
+func (c *ClusterStore) Primary() DB {
+	return c.primary
+}
+
+// Replica pins a logical operation to one replica so that its reads share
+// a consistent view.
+func (c *ClusterStore) Replica() DB {
+	return c.replica()
+}
+
+func (c *ClusterStore) replica() *DBStore {
+	if len(c.replicas) == 0 {
+		return c.primary
+	}
+	n := int(atomic.AddUint32(&c.next, 1) - 1)
+	c.lagMu.RLock()
+	defer c.lagMu.RUnlock()
+	for i := range c.replicas {
+		j := (n + i) % len(c.replicas)
+		if c.lagging == nil || !c.lagging[j] {
+			return c.replicas[j]
+		}
+	}
+	return c.primary
+}
+
+func (c *ClusterStore) Query(sql string, args ...interface{}) (*sql.Rows, error) {
+	return c.replica().Query(sql, args...)
+}
+
+func (c *ClusterStore) Exec(sql string, args ...interface{}) (sql.Result, error) {
+	return c.primary.Exec(sql, args...)
+}
+
+func (c *ClusterStore) SetError(err error) {}
+
+func (c *ClusterStore) BeginTx(ctx context.Context) (TX, error) {
+	return c.primary.BeginTx(ctx)
+}
+
+func (c *ClusterStore) Close() error {
+	c.SetMaxReplicaLag(0, 0)
+	err := c.primary.Close()
+	for _, r := range c.replicas {
+		if rerr := r.Close(); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
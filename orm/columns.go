@@ -0,0 +1,95 @@
+package orm
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// ColumnInfo describes a result column for encoders that must tell numbers,
+// strings and dates apart. Nullable is only meaningful if HasNullable is
+// set, as not every driver reports it.
+type ColumnInfo struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"type"`
+	Nullable     bool   `json:"nullable"`
+	HasNullable  bool   `json:"-"`
+}
+
+func ColumnInfos(rows *sql.Rows) ([]ColumnInfo, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]ColumnInfo, len(types))
+	for i, ct := range types {
+		nullable, ok := ct.Nullable()
+		infos[i] = ColumnInfo{
+			Name:         ct.Name(),
+			DatabaseType: ct.DatabaseTypeName(),
+			Nullable:     nullable,
+			HasNullable:  ok,
+		}
+	}
+	return infos, nil
+}
+
+var numericTypes = NewStringSet("TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT", "YEAR",
+	"DECIMAL", "NUMERIC", "FLOAT", "DOUBLE", "REAL", "MONEY", "SMALLMONEY")
+
+func (ci ColumnInfo) numeric() bool {
+	return numericTypes.Contains(strings.TrimPrefix(strings.ToUpper(ci.DatabaseType), "UNSIGNED "))
+}
+
+// convert turns the driver bytes of a numeric column into a json.Number and
+// others into a string.
+func (ci ColumnInfo) convert(v interface{}) interface{} {
+	switch b := v.(type) {
+	case []byte:
+		if ci.numeric() && json.Valid(b) {
+			return json.Number(b)
+		}
+		return string(b)
+	}
+	return v
+}
+
+// RowsToTypedMaps is RowsToMaps returning the column descriptions too, with
+// numeric columns as json.Number and other byte values as strings. rows is
+// always closed.
+func RowsToTypedMaps(rows *sql.Rows) ([]map[string]interface{}, []ColumnInfo, error) {
+	return rowsToTypedMaps(rows, &rowCounter{})
+}
+
+// RowsToTypedMapsMax is RowsToTypedMaps failing with ErrTooManyRows after
+// max rows, unless max is 0.
+func RowsToTypedMapsMax(rows *sql.Rows, max int64) ([]map[string]interface{}, []ColumnInfo, error) {
+	return rowsToTypedMaps(rows, &rowCounter{max: max})
+}
+
+func rowsToTypedMaps(rows *sql.Rows, limit *rowCounter) ([]map[string]interface{}, []ColumnInfo, error) {
+	defer rows.Close()
+	infos, err := ColumnInfos(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns := make([]string, len(infos))
+	for i, ci := range infos {
+		columns[i] = ci.Name
+	}
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		if err := limit.add(); err != nil {
+			return nil, nil, err
+		}
+		row, err := scanRowMap(rows, columns)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, ci := range infos {
+			row[ci.Name] = ci.convert(row[ci.Name])
+		}
+		result = append(result, row)
+	}
+	return result, infos, rows.Err()
+}
@@ -0,0 +1,207 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+type DBConfig struct {
+	Driver   string
+	Host     string
+	Port     int
+	Database string
+	UserName string
+	Password string
+	// DisableAutocommit turns mysql autocommit off, see SetAutocommit.
+	DisableAutocommit bool
+	// Charset is the mysql connection charset and defaults to utf8mb4.
+	// mssql has no connection charset: strings are always sent as UTF-16
+	// nvarchar and varchar columns follow their collation, so mssql only
+	// accepts utf8 or utf8mb4 here and rejects anything else.
+	Charset string
+	// ConnectAttrs are sent to mysql as connection attributes, shown in
+	// performance_schema.session_connect_attrs (e.g. program_name).
+	ConnectAttrs map[string]string
+
+	// Encrypt is the mssql encrypt mode: "true", "false" or "disable".
+	Encrypt                string
+	TrustServerCertificate bool
+	AppName                string
+	// ConnectionTimeout is the mssql login timeout, in whole seconds.
+	ConnectionTimeout time.Duration
+
+	// DialTimeout bounds establishing the TCP connection, so an unreachable
+	// host fails fast instead of after the OS timeout: mysql timeout and
+	// mssql dial timeout, in whole seconds. It defaults to 5s; negative
+	// leaves it to the driver. A timeout in Params takes precedence.
+	DialTimeout time.Duration
+
+	// Params are passed to the driver as is, as DSN query parameters for
+	// mysql (e.g. readTimeout, interpolateParams) and as key=value pairs for
+	// mssql (e.g. dial timeout, keepAlive).
+	Params map[string]string
+}
+
+func (cfg *DBConfig) DSN() (string, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "mysql":
+		charset := cfg.Charset
+		if charset == "" {
+			charset = "utf8mb4"
+		}
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&autocommit=%t&parseTime=True",
+			cfg.UserName,
+			cfg.Password,
+			cfg.Host,
+			cfg.Port,
+			cfg.Database,
+			charset,
+			!cfg.DisableAutocommit)
+		if d := cfg.dialTimeout(); d > 0 && !hasKey(cfg.Params, "timeout") {
+			dsn += "&timeout=" + d.String()
+		}
+		if len(cfg.ConnectAttrs) > 0 {
+			dsn += "&connectionAttributes=" + url.QueryEscape(joinConnectAttrs(cfg.ConnectAttrs))
+		}
+		for _, k := range sortedKeys(cfg.Params) {
+			dsn += "&" + url.QueryEscape(k) + "=" + url.QueryEscape(cfg.Params[k])
+		}
+		return dsn, nil
+	case "mssql":
+		switch strings.ToLower(cfg.Charset) {
+		case "", "utf8", "utf8mb4":
+		default:
+			return "", fmt.Errorf("mssql does not support charset %s, use nvarchar columns or a column collation", cfg.Charset)
+		}
+		dsn := fmt.Sprintf("server=%s;user id=%s;password=%s;port=%d;database=%s",
+			cfg.Host, cfg.UserName, cfg.Password, cfg.Port, cfg.Database)
+		if cfg.Encrypt != "" {
+			dsn += ";encrypt=" + cfg.Encrypt
+		}
+		if cfg.TrustServerCertificate {
+			dsn += ";TrustServerCertificate=true"
+		}
+		if cfg.AppName != "" {
+			dsn += ";app name=" + cfg.AppName
+		}
+		if cfg.ConnectionTimeout > 0 {
+			dsn += fmt.Sprintf(";connection timeout=%d", int(cfg.ConnectionTimeout/time.Second))
+		}
+		if d := cfg.dialTimeout(); d > 0 && !hasKey(cfg.Params, "dial timeout") {
+			dsn += fmt.Sprintf(";dial timeout=%d", int((d+time.Second-1)/time.Second))
+		}
+		for _, k := range sortedKeys(cfg.Params) {
+			if strings.ContainsAny(k, ";=") || strings.Contains(cfg.Params[k], ";") {
+				return "", fmt.Errorf("invalid mssql param: %s", k)
+			}
+			dsn += ";" + k + "=" + cfg.Params[k]
+		}
+		return dsn, nil
+	}
+	return "", fmt.Errorf("unsupport db driver: %s", cfg.Driver)
+}
+
+// defaultDialTimeout is the DialTimeout of a zero DBConfig.
+const defaultDialTimeout = 5 * time.Second
+
+func (cfg *DBConfig) dialTimeout() time.Duration {
+	if cfg.DialTimeout == 0 {
+		return defaultDialTimeout
+	}
+	return cfg.DialTimeout
+}
+
+// hasKey reports whether params has key, ignoring case like mssql does.
+func hasKey(params map[string]string, key string) bool {
+	for k := range params {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinConnectAttrs(attrs map[string]string) string {
+	pairs := make([]string, 0, len(attrs))
+	for k, v := range attrs {
+		pairs = append(pairs, k+":"+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func NewDBStoreConfig(cfg *DBConfig) (*DBStore, error) {
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(cfg.Driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %v", RedactDSN(dsn), err)
+	}
+	conn, err := newConnector(db.Driver(), dsn)
+	db.Close()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %v", RedactDSN(dsn), err)
+	}
+	cfgCopy := *cfg
+	conn.cfg = &cfgCopy
+	conn.user, conn.pass = cfg.UserName, cfg.Password
+	store := &DBStore{DB: sql.OpenDB(conn), connector: conn, cache: newQueryCache()}
+	store.activity = &activity{}
+	store.driver = strings.ToLower(cfg.Driver)
+	return store, nil
+}
+
+// RedactDSN masks the password of a mysql, mssql or URL style DSN with ***
+// so the DSN can be logged.
+func RedactDSN(dsn string) string {
+	if i := strings.Index(dsn, "://"); i >= 0 {
+		host := i + 3
+		at := strings.Index(dsn[host:], "@")
+		if end := strings.IndexAny(dsn[host:], "/?"); at < 0 || end >= 0 && end < at {
+			return dsn
+		}
+		if colon := strings.Index(dsn[host:host+at], ":"); colon >= 0 {
+			return dsn[:host+colon+1] + "***" + dsn[host+at:]
+		}
+		return dsn
+	}
+	if strings.Contains(dsn, ";") || strings.HasPrefix(strings.ToLower(dsn), "server=") {
+		parts := strings.Split(dsn, ";")
+		for i, part := range parts {
+			kv := strings.SplitN(part, "=", 2)
+			switch strings.ToLower(strings.TrimSpace(kv[0])) {
+			case "password", "pwd":
+				parts[i] = kv[0] + "=***"
+			}
+		}
+		return strings.Join(parts, ";")
+	}
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return dsn
+	}
+	at := strings.LastIndex(dsn[:slash], "@")
+	if at < 0 {
+		return dsn
+	}
+	colon := strings.Index(dsn[:at], ":")
+	if colon < 0 {
+		return dsn
+	}
+	return dsn[:colon+1] + "***" + dsn[at:]
+}
@@ -0,0 +1,19 @@
+package orm
+
+import "testing"
+
+func TestRedactDSN(t *testing.T) {
+	cases := []struct {
+		dsn, out string
+	}{
+		{"root:s3cr@t@tcp(127.0.0.1:3306)/blog?charset=utf8mb4", "root:***@tcp(127.0.0.1:3306)/blog?charset=utf8mb4"},
+		{"root@tcp(127.0.0.1:3306)/blog", "root@tcp(127.0.0.1:3306)/blog"},
+		{"server=db;user id=sa;password=p;w;port=1433", "server=db;user id=sa;password=***;w;port=1433"},
+		{"sqlserver://sa:secret@db:1433?database=blog", "sqlserver://sa:***@db:1433?database=blog"},
+	}
+	for i, c := range cases {
+		if out := RedactDSN(c.dsn); out != c.out {
+			t.Errorf("#%d expected %q, got %q", i+1, c.out, out)
+		}
+	}
+}
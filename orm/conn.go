@@ -0,0 +1,157 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ConnDB runs statements on one connection pinned from a store's pool, for
+// session state that must hold across statements. Close it to hand the
+// connection back.
+type ConnDB struct {
+	options
+	conn    *sql.Conn
+	ctx     context.Context
+	release func(ctx context.Context) error
+	err     error
+	closed  bool
+}
+
+func (store *DBStore) pin(ctx context.Context) (*ConnDB, error) {
+	if err := store.activity.enter(); err != nil {
+		return nil, err
+	}
+	conn, err := store.acquire(ctx)
+	if err != nil {
+		store.activity.leave()
+		return nil, err
+	}
+	return &ConnDB{options: store.options, conn: conn, ctx: ctx}, nil
+}
+
+func (c *ConnDB) Query(sql string, args ...interface{}) (*sql.Rows, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	sql, err := c.prepare(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	done := c.statement(c.ctx, sql, args)
+	rows, err := c.conn.QueryContext(c.ctx, sql, args...)
+	err = c.queryError(sql, args, err)
+	if err = done(err); err != nil && rows != nil {
+		rows.Close()
+		return nil, err
+	}
+	return rows, err
+}
+
+func (c *ConnDB) Exec(sql string, args ...interface{}) (sql.Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	sql, err := c.prepare(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	done := c.statement(c.ctx, sql, args)
+	result, err := c.conn.ExecContext(c.ctx, sql, args...)
+	err = c.queryError(sql, args, err)
+	if err == nil {
+		c.observeRows(sql, result)
+	}
+	return result, done(err)
+}
+
+func (c *ConnDB) SetError(err error) {}
+
+func (c *ConnDB) BeginTx(ctx context.Context) (TX, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if ctx == nil {
+		ctx = c.ctx
+	}
+	tx, err := c.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DBTx{tx: tx, options: c.options, ctx: ctx}, nil
+}
+
+// Close undoes the session state set up for the connection and returns it
+// to the pool.
+func (c *ConnDB) Close() error {
+	if c.conn == nil || c.closed {
+		return c.err
+	}
+	c.closed = true
+	defer c.activity.leave()
+	if c.release != nil {
+		if err := c.release(c.ctx); err != nil {
+			c.discard()
+			if err == errDiscardConn {
+				return nil
+			}
+			return err
+		}
+	}
+	return c.conn.Close()
+}
+
+// discard closes the connection instead of returning it to the pool, for
+// session state that can not be undone.
+func (c *ConnDB) discard() {
+	c.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	c.conn.Close()
+}
+
+var (
+	schemaName = regexp.MustCompile(`^[A-Za-z0-9_$]+$`)
+
+	// errDiscardConn is returned by a release func whose session state can
+	// only be undone by closing the connection.
+	errDiscardConn = errors.New("discard connection")
+)
+
+// WithSchema pins a connection switched to database schema with USE, so
+// that statements of one tenant stay in its schema. The returned DB is a
+// *ConnDB and must be closed, which switches the connection back before it
+// returns to the pool, or discards it if that is not possible. Failures are
+// returned by its statements.
+func (store *DBStore) WithSchema(schema string) DB {
+	if !schemaName.MatchString(schema) {
+		return &ConnDB{err: fmt.Errorf("invalid schema name: %q", schema)}
+	}
+	ctx := store.context()
+	c, err := store.pin(ctx)
+	if err != nil {
+		return &ConnDB{err: err}
+	}
+	current := "SELECT DATABASE()"
+	if store.driver == "mssql" {
+		current = "SELECT DB_NAME()"
+	}
+	var prev sql.NullString
+	if err := c.conn.QueryRowContext(ctx, current).Scan(&prev); err != nil {
+		c.Close()
+		return &ConnDB{err: err}
+	}
+	if _, err := c.conn.ExecContext(ctx, "USE "+quoteIdent(store.driver, schema)); err != nil {
+		c.Close()
+		return &ConnDB{err: err}
+	}
+	c.release = func(ctx context.Context) error {
+		if !prev.Valid {
+			return errDiscardConn
+		}
+		_, err := c.conn.ExecContext(ctx, "USE "+quoteIdent(store.driver, prev.String))
+		return err
+	}
+	return c
+}
@@ -0,0 +1,352 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// connector opens the connections of a DBStore pool, wrapping each so the
+// store can observe the connection life cycle.
+type connector struct {
+	base driver.Connector
+	drv  driver.Driver
+	seq  uint64
+	diag int32
+	// gen is bumped by RefreshConnections; connections of an older
+	// generation are discarded instead of reused.
+	gen uint64
+
+	mu        sync.Mutex
+	conns     map[*observedConn]struct{}
+	onConnect []func(ctx context.Context, exec SessionExec) error
+
+	// cfg and credentials rebuild the DSN of every new connection, see
+	// SetCredentialProvider; base is kept for the last credentials.
+	cfg         *DBConfig
+	credentials func(ctx context.Context) (user, pass string, err error)
+	user, pass  string
+}
+
+// dsnConnector adapts a driver without driver.DriverContext.
+type dsnConnector struct {
+	drv driver.Driver
+	dsn string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.drv.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+func newConnector(drv driver.Driver, dsn string) (*connector, error) {
+	c := &connector{drv: drv, conns: map[*observedConn]struct{}{}}
+	base, err := openConnector(drv, dsn)
+	if err != nil {
+		return nil, err
+	}
+	c.base = base
+	return c, nil
+}
+
+func openConnector(drv driver.Driver, dsn string) (driver.Connector, error) {
+	if dc, ok := drv.(driver.DriverContext); ok {
+		return dc.OpenConnector(dsn)
+	}
+	return dsnConnector{drv: drv, dsn: dsn}, nil
+}
+
+// connectorFor returns the connector for the current credentials, opening
+// a new one when the provider hands out different ones.
+func (c *connector) connectorFor(ctx context.Context) (driver.Connector, error) {
+	c.mu.Lock()
+	provider := c.credentials
+	c.mu.Unlock()
+	if provider == nil {
+		return c.base, nil
+	}
+	user, pass, err := provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if user == c.user && pass == c.pass {
+		return c.base, nil
+	}
+	cfg := *c.cfg
+	cfg.UserName, cfg.Password = user, pass
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
+	base, err := openConnector(c.drv, dsn)
+	if err != nil {
+		return nil, err
+	}
+	c.base, c.user, c.pass = base, user, pass
+	return base, nil
+}
+
+func (c *connector) diagnostics() bool {
+	return atomic.LoadInt32(&c.diag) == 1
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	id := atomic.AddUint64(&c.seq, 1)
+	t1 := time.Now()
+	base, err := c.connectorFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := base.Connect(ctx)
+	if c.diagnostics() {
+		log.Println("CONN: ", id, "open", time.Now().Sub(t1).String(), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	hooks := c.onConnect
+	c.mu.Unlock()
+	for _, fn := range hooks {
+		if err := fn(ctx, sessionExec(conn)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	oc := &observedConn{Conn: conn, id: id, c: c, gen: atomic.LoadUint64(&c.gen)}
+	c.mu.Lock()
+	c.conns[oc] = struct{}{}
+	c.mu.Unlock()
+	return oc, nil
+}
+
+// closeAll closes every open connection under the pool, including those
+// busy with a statement, which then fails.
+func (c *connector) closeAll() {
+	c.mu.Lock()
+	conns := make([]*observedConn, 0, len(c.conns))
+	for oc := range c.conns {
+		conns = append(conns, oc)
+	}
+	c.mu.Unlock()
+	for _, oc := range conns {
+		oc.Close()
+	}
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.drv
+}
+
+// SessionExec runs a statement on a connection being set up.
+type SessionExec func(ctx context.Context, query string) error
+
+func sessionExec(conn driver.Conn) SessionExec {
+	return func(ctx context.Context, query string) error {
+		if e, ok := conn.(driver.ExecerContext); ok {
+			_, err := e.ExecContext(ctx, query, nil)
+			if err != driver.ErrSkip {
+				return err
+			}
+		}
+		stmt, err := conn.Prepare(query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		_, err = stmt.Exec(nil)
+		return err
+	}
+}
+
+// OnConnect runs fn on every new connection before it joins the pool, e.g.
+// to set session variables; an error fails the connect. It does not touch
+// connections already open, so call it during setup.
+func (store *DBStore) OnConnect(fn func(ctx context.Context, exec SessionExec) error) {
+	if store.connector == nil {
+		return
+	}
+	store.connector.mu.Lock()
+	defer store.connector.mu.Unlock()
+	store.connector.onConnect = append(store.connector.onConnect, fn)
+}
+
+// SetCredentialProvider makes every new connection log in with the user
+// and password fn returns, so rotated credentials, e.g. RDS IAM tokens, take
+// effect without recreating the store. Open connections keep theirs until
+// they are closed, see RefreshConnections. Only stores opened by
+// NewDBStoreConfig support it.
+func (store *DBStore) SetCredentialProvider(fn func(ctx context.Context) (user, pass string, err error)) error {
+	if store.connector == nil || store.connector.cfg == nil {
+		return errors.New("credential provider requires a store opened by NewDBStoreConfig")
+	}
+	store.connector.mu.Lock()
+	defer store.connector.mu.Unlock()
+	store.connector.credentials = fn
+	return nil
+}
+
+// defaultMaxIdleConns is the database/sql default idle pool size.
+const defaultMaxIdleConns = 2
+
+// SetMaxIdleConns is sql.DB.SetMaxIdleConns, remembered so that
+// RefreshConnections can restore it.
+func (store *DBStore) SetMaxIdleConns(n int) {
+	store.maxIdle, store.maxIdleSet = n, true
+	store.DB.SetMaxIdleConns(n)
+}
+
+// RefreshConnections retires every open connection, e.g. right after a
+// credential rotation, so that new connections log in with the current
+// credentials: idle ones are closed now and busy ones when they are
+// released. It returns the error of pinging through a new connection.
+func (store *DBStore) RefreshConnections(ctx context.Context) error {
+	if store.connector == nil {
+		return errors.New("refreshing connections requires a store opened by NewDBStoreConfig")
+	}
+	atomic.AddUint64(&store.connector.gen, 1)
+	maxIdle := defaultMaxIdleConns
+	if store.maxIdleSet {
+		maxIdle = store.maxIdle
+	}
+	store.DB.SetMaxIdleConns(-1)
+	store.DB.SetMaxIdleConns(maxIdle)
+	return store.PingContext(ctx)
+}
+
+// SetConnDiagnostics toggles logging of connections being opened, closed
+// and validated, independently of the statement level Debug log. While on,
+// pooled connections are pinged before they are reused and discarded if
+// the ping fails.
+func (store *DBStore) SetConnDiagnostics(b bool) {
+	if store.connector == nil {
+		return
+	}
+	var v int32
+	if b {
+		v = 1
+	}
+	atomic.StoreInt32(&store.connector.diag, v)
+}
+
+// observedConn forwards every optional driver interface to the wrapped
+// connection so that wrapping does not change driver behaviour.
+type observedConn struct {
+	driver.Conn
+	id  uint64
+	c   *connector
+	gen uint64
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+var (
+	_ driver.ConnPrepareContext = &observedConn{}
+	_ driver.ConnBeginTx        = &observedConn{}
+	_ driver.QueryerContext     = &observedConn{}
+	_ driver.ExecerContext      = &observedConn{}
+	_ driver.Pinger             = &observedConn{}
+	_ driver.SessionResetter    = &observedConn{}
+	_ driver.NamedValueChecker  = &observedConn{}
+)
+
+func (oc *observedConn) Close() error {
+	oc.closeOnce.Do(func() {
+		oc.c.mu.Lock()
+		delete(oc.c.conns, oc)
+		oc.c.mu.Unlock()
+		oc.closeErr = oc.Conn.Close()
+		if oc.c.diagnostics() {
+			log.Println("CONN: ", oc.id, "close", oc.closeErr)
+		}
+	})
+	return oc.closeErr
+}
+
+func (oc *observedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := oc.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return oc.Conn.Prepare(query)
+}
+
+func (oc *observedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := oc.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("driver does not support transaction options")
+	}
+	return oc.Conn.Begin()
+}
+
+func (oc *observedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := oc.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (oc *observedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := oc.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (oc *observedConn) Ping(ctx context.Context) error {
+	if p, ok := oc.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (oc *observedConn) ResetSession(ctx context.Context) error {
+	if oc.stale() {
+		return driver.ErrBadConn
+	}
+	if r, ok := oc.Conn.(driver.SessionResetter); ok {
+		if err := r.ResetSession(ctx); err != nil {
+			return err
+		}
+	}
+	if oc.c.diagnostics() {
+		err := oc.Ping(ctx)
+		log.Println("CONN: ", oc.id, "validate", err)
+		if err != nil {
+			return driver.ErrBadConn
+		}
+	}
+	return nil
+}
+
+func (oc *observedConn) stale() bool {
+	return oc.gen != atomic.LoadUint64(&oc.c.gen)
+}
+
+func (oc *observedConn) IsValid() bool {
+	if oc.stale() {
+		return false
+	}
+	if v, ok := oc.Conn.(interface{ IsValid() bool }); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (oc *observedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if c, ok := oc.Conn.(driver.NamedValueChecker); ok {
+		return c.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
@@ -0,0 +1,141 @@
+package orm
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/go-sql-driver/mysql"
+)
+
+var _copySeq uint64
+
+// CopyFrom bulk loads the rows produced by next into table through the
+// driver's native path: LOAD DATA LOCAL INFILE for mysql (the server must
+// allow local_infile) and the bulk copy protocol for mssql. next returns
+// false once there are no more rows. It returns the number of rows loaded.
+func (store *DBStore) CopyFrom(ctx context.Context, table string, columns []string, next func() ([]interface{}, bool)) (int64, error) {
+	if err := checkIdents(append([]string{table}, columns...)...); err != nil {
+		return 0, err
+	}
+	switch store.driver {
+	case "mysql":
+		return store.loadData(ctx, table, columns, next)
+	case "mssql":
+		return store.bulkCopy(ctx, table, columns, next)
+	}
+	return 0, fmt.Errorf("unsupport db driver: %s", store.driver)
+}
+
+func (store *DBStore) loadData(ctx context.Context, table string, columns []string, next func() ([]interface{}, bool)) (int64, error) {
+	name := fmt.Sprintf("orm.copy.%d", atomic.AddUint64(&_copySeq, 1))
+	pr, pw := io.Pipe()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(name)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(writeLoadData(pw, next))
+	}()
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdent(store.driver, col)
+	}
+	query := fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s (%s)",
+		name, quoteIdent(store.driver, table), strings.Join(quoted, ", "))
+	result, err := store.ExecContext(ctx, query)
+	pr.Close()
+	<-done
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// writeLoadData writes rows in the default LOAD DATA format: tab separated
+// fields, newline terminated lines, backslash escapes and \N for NULL.
+func writeLoadData(w io.Writer, next func() ([]interface{}, bool)) error {
+	bw := bufio.NewWriter(w)
+	escaper := strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x00", `\0`)
+	for {
+		row, ok := next()
+		if !ok {
+			break
+		}
+		for i, v := range row {
+			if i > 0 {
+				bw.WriteByte('\t')
+			}
+			if valuer, ok := v.(driver.Valuer); ok {
+				var err error
+				if v, err = valuer.Value(); err != nil {
+					return err
+				}
+			}
+			switch v := v.(type) {
+			case nil:
+				bw.WriteString(`\N`)
+			case []byte:
+				escaper.WriteString(bw, string(v))
+			case time.Time:
+				bw.WriteString(v.Format("2006-01-02 15:04:05.999999"))
+			case bool:
+				if v {
+					bw.WriteByte('1')
+				} else {
+					bw.WriteByte('0')
+				}
+			default:
+				escaper.WriteString(bw, fmt.Sprint(v))
+			}
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func (store *DBStore) bulkCopy(ctx context.Context, table string, columns []string, next func() ([]interface{}, bool)) (int64, error) {
+	if err := store.checkWritable(); err != nil {
+		return 0, err
+	}
+	txn, err := store.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := txn.PrepareContext(ctx, mssql.CopyIn(table, mssql.BulkOptions{}, columns...))
+	if err != nil {
+		txn.Rollback()
+		return 0, err
+	}
+	for {
+		row, ok := next()
+		if !ok {
+			break
+		}
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			stmt.Close()
+			txn.Rollback()
+			return 0, err
+		}
+	}
+	result, err := stmt.ExecContext(ctx)
+	stmt.Close()
+	if err != nil {
+		txn.Rollback()
+		return 0, err
+	}
+	if err := txn.Commit(); err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
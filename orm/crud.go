@@ -0,0 +1,153 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrFullTableUpdate is returned by Update without a WHERE condition unless
+// the store came from AllowFullTableUpdate.
+var ErrFullTableUpdate = errors.New("update without where condition")
+
+// ErrFullTableDelete is returned by Delete without a WHERE condition unless
+// the store came from AllowFullTableDelete.
+var ErrFullTableDelete = errors.New("delete without where condition")
+
+// insertColumns sorts the columns of cols for a deterministic statement and
+// returns them quoted along with their values.
+func insertColumns(driver string, cols map[string]interface{}) ([]string, []interface{}) {
+	names := make([]string, 0, len(cols))
+	for name := range cols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	quoted := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(driver, name)
+		args[i] = cols[name]
+	}
+	return quoted, args
+}
+
+// Insert inserts row, its columns ordered by name.
+func (store *DBStore) Insert(ctx context.Context, table string, row map[string]interface{}) (sql.Result, error) {
+	if len(row) == 0 {
+		return nil, fmt.Errorf("no columns to insert into %s", table)
+	}
+	if err := checkColumns(table, row); err != nil {
+		return nil, err
+	}
+	quoted, args := insertColumns(store.driver, row)
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdent(store.driver, table),
+		strings.Join(quoted, ", "),
+		strings.Join(NewStringSlice(len(args), "?"), ","))
+	return store.ExecContext(ctx, query, args...)
+}
+
+// InsertIgnore inserts a row unless it violates a unique key, in which case
+// nothing happens and RowsAffected is 0. mysql uses INSERT IGNORE, which
+// also downgrades other errors such as bad values to warnings; mssql
+// swallows duplicate key errors only.
+func (store *DBStore) InsertIgnore(table string, cols map[string]interface{}) (sql.Result, error) {
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("no columns to insert into %s", table)
+	}
+	if err := checkColumns(table, cols); err != nil {
+		return nil, err
+	}
+	quoted, args := insertColumns(store.driver, cols)
+	insert := fmt.Sprintf("INTO %s (%s) VALUES (%s)",
+		quoteIdent(store.driver, table),
+		strings.Join(quoted, ", "),
+		strings.Join(NewStringSlice(len(args), "?"), ","))
+	switch store.driver {
+	case "mysql":
+		return store.Exec("INSERT IGNORE "+insert, args...)
+	case "mssql":
+		return store.Exec("BEGIN TRY INSERT "+insert+" END TRY BEGIN CATCH IF ERROR_NUMBER() NOT IN (2601, 2627) THROW; END CATCH", args...)
+	}
+	return nil, fmt.Errorf("unsupport db driver: %s", store.driver)
+}
+
+// whereEquals builds the AND of col = ? conditions of where, ordered by
+// column, with nil values as col IS NULL.
+func whereEquals(driver string, where map[string]interface{}) (string, []interface{}) {
+	names := make([]string, 0, len(where))
+	for name := range where {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	conditions := make([]string, 0, len(names))
+	args := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		if where[name] == nil {
+			conditions = append(conditions, quoteIdent(driver, name)+" IS NULL")
+			continue
+		}
+		conditions = append(conditions, quoteIdent(driver, name)+" = ?")
+		args = append(args, where[name])
+	}
+	return SQLWhere(conditions), args
+}
+
+// AllowFullTableUpdate returns a clone of store whose Update accepts an
+// empty where, updating every row.
+func (store *DBStore) AllowFullTableUpdate() *DBStore {
+	c := store.Clone()
+	c.allowFullUpdate = true
+	return c
+}
+
+// Update sets the set columns of the rows matching every column of where
+// and returns the number of rows affected. An empty where fails with
+// ErrFullTableUpdate, see AllowFullTableUpdate.
+func (store *DBStore) Update(ctx context.Context, table string, set map[string]interface{}, where map[string]interface{}) (int64, error) {
+	if len(set) == 0 {
+		return 0, fmt.Errorf("no columns to update in %s", table)
+	}
+	if len(where) == 0 && !store.allowFullUpdate {
+		return 0, ErrFullTableUpdate
+	}
+	if err := checkColumns(table, set); err != nil {
+		return 0, err
+	}
+	if err := checkColumns(table, where); err != nil {
+		return 0, err
+	}
+	quoted, args := insertColumns(store.driver, set)
+	for i := range quoted {
+		quoted[i] += " = ?"
+	}
+	clause, whereArgs := whereEquals(store.driver, where)
+	query := fmt.Sprintf("UPDATE %s SET %s %s", quoteIdent(store.driver, table), strings.Join(quoted, ", "), clause)
+	return store.ExecAffected(ctx, query, append(args, whereArgs...)...)
+}
+
+// AllowFullTableDelete returns a clone of store whose Delete accepts an
+// empty where, deleting every row.
+func (store *DBStore) AllowFullTableDelete() *DBStore {
+	c := store.Clone()
+	c.allowFullDelete = true
+	return c
+}
+
+// Delete deletes the rows matching every column of where and returns the
+// number of rows affected. An empty where fails with ErrFullTableDelete,
+// see AllowFullTableDelete.
+func (store *DBStore) Delete(ctx context.Context, table string, where map[string]interface{}) (int64, error) {
+	if len(where) == 0 && !store.allowFullDelete {
+		return 0, ErrFullTableDelete
+	}
+	if err := checkColumns(table, where); err != nil {
+		return 0, err
+	}
+	clause, args := whereEquals(store.driver, where)
+	query := fmt.Sprintf("DELETE FROM %s %s", quoteIdent(store.driver, table), clause)
+	return store.ExecAffected(ctx, query, args...)
+}
@@ -5,7 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"strings"
+	"math/rand"
 	"time"
 
 	_ "github.com/denisenkom/go-mssqldb"
@@ -23,15 +23,72 @@ type DB interface {
 	BeginTx(ctx context.Context) (TX, error)
 }
 
+// ContextDB is a DB taking a context per statement, implemented by both
+// DBStore and DBTx so code can pass deadlines without knowing which it has.
+type ContextDB interface {
+	DB
+	QueryContext(ctx context.Context, sql string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, sql string, args ...interface{}) (sql.Result, error)
+}
+
+var (
+	_ ContextDB = &DBStore{}
+	_ ContextDB = &DBTx{}
+)
+
 type TracedDB struct {
 	DB
 	ctx context.Context
+	// rate is the fraction of statements traced when sampled is set.
+	sampled bool
+	rate    float64
 }
 
 type DBStore struct {
 	*sql.DB
+	options
+	retry   RetryConfig
+	baseCtx context.Context
+	cache   *queryCache
+
+	connector *connector
+	readOnly  int32
+	clone     bool
+
+	acquireTimeout time.Duration
+	maxIdle        int
+	maxIdleSet     bool
+}
+
+// options are the per-statement settings a DBStore hands down to its
+// transactions.
+type options struct {
+	driver  string
 	debug   bool
 	slowlog time.Duration
+	metrics Metrics
+	before  BeforeHook
+	after   AfterHook
+
+	checkArgs     bool
+	checkArgTypes bool
+	maxArgs       int
+	fault         func(sql string) error
+	rewrite       func(sql string) string
+
+	slowEvents  *slowQueryEvents
+	slowAsError bool
+	redactArgs  bool
+	logOnError  bool
+	maxRows     int64
+	slowSampler *slowLogSampler
+	activity    *activity
+	txTracker   *txTracker
+	txIdle      time.Duration
+	slowTx      time.Duration
+
+	allowFullUpdate bool
+	allowFullDelete bool
 }
 
 type TX interface {
@@ -48,12 +105,21 @@ type TX interface {
 
 
 type DBTx struct {
+	options
 	tx           *sql.Tx
-	debug        bool
-	slowlog      time.Duration
 	err          error
 	rowsAffected int64
 	ctx          context.Context
+	cancel       context.CancelFunc
+	onCommit     []func()
+	onRollback   []func()
+	active       bool
+	rollbackOnly bool
+	conn         *sql.Conn
+	lastStmt     time.Time
+	lastSQL      string
+	started      time.Time
+	stmts        int
 }
 
 func (tx *DBTx) Prepare(query string) (*sql.Stmt, error) {
@@ -65,11 +131,11 @@ func (tx *DBTx) QueryRow(query string, args ...interface{}) *sql.Row {
 }
 
 func (tx *DBTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-    return tx.tx.ExecContext(ctx, query, args...)
+	return tx.exec(ctx, query, args)
 }
 
 func (tx *DBTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-    return tx.tx.QueryContext(ctx, query, args...)
+	return tx.query(ctx, query, args)
 }
 
 func (tx *DBTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
@@ -89,53 +155,47 @@ type DBQuerySession struct {
 }
 
 func NewDBStore(driver, host string, port int, database, username, password string) (*DBStore, error) {
-	var dsn string
-	switch strings.ToLower(driver) {
-	case "mysql":
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&autocommit=true&parseTime=True",
-			username,
-			password,
-			host,
-			port,
-			database)
-	case "mssql":
-		dsn = fmt.Sprintf("server=%s;user id=%s;password=%s;port=%d;database=%s",
-			host, username, password, port, database)
-	default:
-		return nil, fmt.Errorf("unsupport db driver: %s", driver)
-	}
-	db, err := sql.Open(driver, dsn)
-	if err != nil {
-		return nil, err
-	}
-	return &DBStore{db, false, time.Duration(0)}, nil
+	return NewDBStoreConfig(&DBConfig{
+		Driver:   driver,
+		Host:     host,
+		Port:     port,
+		Database: database,
+		UserName: username,
+		Password: password,
+	})
 }
 
+// NewDBStoreCharset is NewDBStore with the mysql connection charset. mssql
+// only accepts utf8 or utf8mb4, see DBConfig.Charset.
 func NewDBStoreCharset(driver, host string, port int, database, username, password, charset string) (*DBStore, error) {
-	var dsn string
-	switch strings.ToLower(driver) {
-	case "mysql":
-		if charset == "" {
-			charset = "utf8"
-		}
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&autocommit=true&parseTime=True",
-			username,
-			password,
-			host,
-			port,
-			database,
-			charset)
-	case "mssql":
-		dsn = fmt.Sprintf("server=%s;user id=%s;password=%s;port=%d;database=%s",
-			host, username, password, port, database)
-	default:
-		return nil, fmt.Errorf("unsupport db driver: %s", driver)
-	}
-	db, err := sql.Open(driver, dsn)
-	if err != nil {
-		return nil, err
+	if charset == "" {
+		charset = "utf8"
 	}
-	return &DBStore{db, false, time.Duration(0)}, nil
+	return NewDBStoreConfig(&DBConfig{
+		Driver:   driver,
+		Host:     host,
+		Port:     port,
+		Database: database,
+		UserName: username,
+		Password: password,
+		Charset:  charset,
+	})
+}
+
+// Clone derives a store sharing the connection pool of store, whose debug,
+// slow log and other statement settings can be changed independently. The
+// clone shares the transaction tracking of store until TrackTransactions is
+// called on it, and starts with its own slow log sampling of the same window
+// and without a SlowQueryChan; Drain still covers it. Close on a clone only
+// stops its own tracking; the pool is closed through the original.
+func (store *DBStore) Clone() *DBStore {
+	c := *store
+	c.clone = true
+	c.slowSampler, c.slowEvents = nil, nil
+	if s := store.slowSampler; s != nil {
+		c.SetSlowLogSampling(s.window)
+	}
+	return &c
 }
 
 func (store *DBStore) Debug(b bool) {
@@ -146,41 +206,90 @@ func (store *DBStore) SlowLog(duration time.Duration) {
 	store.slowlog = duration
 }
 
+// SetBaseContext sets the context Query, Exec and BeginTx use when no
+// context is given explicitly.
+func (store *DBStore) SetBaseContext(ctx context.Context) {
+	store.baseCtx = ctx
+}
+
+func (store *DBStore) context() context.Context {
+	if store.baseCtx != nil {
+		return store.baseCtx
+	}
+	return context.Background()
+}
+
 func (store *DBStore) Query(sql string, args ...interface{}) (*sql.Rows, error) {
-	t1 := time.Now()
-	if store.slowlog > 0 {
-		defer func(t time.Time) {
-			span := time.Now().Sub(t1)
-			if span > store.slowlog {
-				log.Println("SLOW: ", span.String(), sql, args)
-			}
-		}(t1)
+	return store.QueryContext(store.context(), sql, args...)
+}
+
+func (store *DBStore) QueryContext(ctx context.Context, sql string, args ...interface{}) (*sql.Rows, error) {
+	if err := store.activity.enter(); err != nil {
+		return nil, err
 	}
-	if store.debug {
-		log.Println("DEBUG: ", sql, args)
+	defer store.activity.leave()
+	sql, err := store.prepare(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	r, conn, err := store.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	done := store.statement(ctx, sql, args)
+	rows, err := r.QueryContext(ctx, sql, args...)
+	releaseAfterRows(conn, rows)
+	err = store.queryError(sql, args, err)
+	if err = done(err); err != nil && rows != nil {
+		rows.Close()
+		return nil, err
 	}
-	return store.DB.Query(sql, args...)
+	return rows, err
 }
 
 func (store *DBStore) Exec(sql string, args ...interface{}) (sql.Result, error) {
-	t1 := time.Now()
-	if store.slowlog > 0 {
-		defer func(t time.Time) {
-			span := time.Now().Sub(t1)
-			if span > store.slowlog {
-				log.Println("SLOW: ", span.String(), sql, args)
-			}
-		}(t1)
+	return store.ExecContext(store.context(), sql, args...)
+}
+
+func (store *DBStore) ExecContext(ctx context.Context, sql string, args ...interface{}) (sql.Result, error) {
+	if err := store.activity.enter(); err != nil {
+		return nil, err
 	}
-	if store.debug {
-		log.Println("DEBUG: ", sql, args)
+	defer store.activity.leave()
+	if err := store.checkWritable(); err != nil {
+		return nil, err
+	}
+	sql, err := store.prepare(sql, args)
+	if err != nil {
+		return nil, err
+	}
+	r, conn, err := store.runner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	done := store.statement(ctx, sql, args)
+	result, err := r.ExecContext(ctx, sql, args...)
+	releaseAfterRows(conn, nil)
+	err = store.queryError(sql, args, err)
+	if err == nil {
+		store.observeRows(sql, result)
 	}
-	return store.DB.Exec(sql, args...)
+	return result, done(err)
 }
 
 func (store *DBStore) SetError(err error) {}
 
+// Unwrap returns the underlying pool for libraries that need a *sql.DB.
+// Statements run on it skip validation, logging, metrics and hooks.
+func (store *DBStore) Unwrap() *sql.DB {
+	return store.DB
+}
+
 func (store *DBStore) Close() error {
+	store.TrackTransactions(0, 0)
+	if store.clone {
+		return nil
+	}
 	if err := store.DB.Close(); err != nil {
 		return err
 	}
@@ -188,18 +297,82 @@ func (store *DBStore) Close() error {
 	return nil
 }
 
+// CloseContext is Close giving up on statements still running when ctx
+// expires: their connections are then closed underneath them and ctx's
+// error is returned.
+func (store *DBStore) CloseContext(ctx context.Context) error {
+	store.TrackTransactions(0, 0)
+	if store.clone {
+		return nil
+	}
+	db := store.DB
+	done := make(chan error, 1)
+	go func() { done <- db.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		store.DB = nil
+		return nil
+	case <-ctx.Done():
+		if store.connector != nil {
+			store.connector.closeAll()
+		}
+		return ctx.Err()
+	}
+}
+
 func (store *DBStore) BeginTx(ctx context.Context) (TX, error) {
-	tx, err := store.Begin()
+	if err := store.checkWritable(); err != nil {
+		return nil, err
+	}
+	return store.beginTx(ctx, nil)
+}
+
+func (store *DBStore) beginTx(ctx context.Context, opts *sql.TxOptions) (TX, error) {
+	if ctx == nil {
+		ctx = store.baseCtx
+	}
+	if err := store.activity.enter(); err != nil {
+		return nil, err
+	}
+	var tx *sql.Tx
+	var conn *sql.Conn
+	err := store.retry.do(ctx, func() (err error) {
+		if store.acquireTimeout <= 0 {
+			tx, err = store.DB.BeginTx(context.Background(), opts)
+			return
+		}
+		actx := ctx
+		if actx == nil {
+			actx = store.context()
+		}
+		if conn, err = store.acquire(actx); err != nil {
+			return
+		}
+		if tx, err = conn.BeginTx(context.Background(), opts); err != nil {
+			conn.Close()
+		}
+		return
+	})
 	if err != nil {
+		store.activity.leave()
 		return nil, err
 	}
 
-	return &DBTx{
+	t := &DBTx{
 		tx:      tx,
-		debug:   store.debug,
-		slowlog: store.slowlog,
+		options: store.options,
 		ctx:     ctx,
-	}, nil
+		active:  true,
+		conn:    conn,
+		started: time.Now(),
+	}
+	if t.txTracker != nil {
+		t.txTracker.add(t)
+	}
+	return t, nil
 }
 
 func (tx *DBTx) BeginTx(ctx context.Context) (TX, error) {
@@ -207,6 +380,21 @@ func (tx *DBTx) BeginTx(ctx context.Context) (TX, error) {
 }
 
 func (tx *DBTx) Close() error {
+	if tx.cancel != nil {
+		defer tx.cancel()
+	}
+	if tx.txTracker != nil {
+		tx.txTracker.remove(tx)
+	}
+	tx.checkIdle()
+	tx.checkSlow()
+	if tx.active {
+		tx.active = false
+		defer tx.activity.leave()
+	}
+	if tx.conn != nil {
+		defer tx.conn.Close()
+	}
 	if tx.ctx != nil {
 		select {
 		case <-tx.ctx.Done():
@@ -214,59 +402,82 @@ func (tx *DBTx) Close() error {
 		default:
 		}
 	}
-	if tx.err != nil {
-		return tx.tx.Rollback()
+	if tx.err != nil || tx.rollbackOnly {
+		if tx.err != nil {
+			log.Println("WARN: ", "rolling back transaction:", tx.err)
+		}
+		err := tx.tx.Rollback()
+		runAll(tx.onRollback)
+		return err
+	}
+	if err := tx.tx.Commit(); err != nil {
+		runAll(tx.onRollback)
+		return err
 	}
-	return tx.tx.Commit()
+	runAll(tx.onCommit)
+	return nil
 }
 
-func (tx *DBTx) Query(sql string, args ...interface{}) (result *sql.Rows, err error) {
-	t1 := time.Now()
-	if tx.slowlog > 0 {
-		defer func(t time.Time) {
-			span := time.Now().Sub(t1)
-			if span > tx.slowlog {
-				log.Println("SLOW: ", span.String(), sql, args)
-			}
-		}(t1)
-	}
-	if tx.debug {
-		log.Println("DEBUG: ", sql, args)
-	}
+func (tx *DBTx) Query(sql string, args ...interface{}) (*sql.Rows, error) {
+	return tx.query(tx.context(), sql, args)
+}
 
-	if tx.ctx != nil {
-		result, err = tx.tx.QueryContext(tx.ctx, sql, args...)
+func (tx *DBTx) query(ctx context.Context, sql string, args []interface{}) (result *sql.Rows, err error) {
+	if sql, err = tx.prepare(sql, args); err != nil {
 		tx.err = err
 		return
 	}
-	result, err = tx.tx.Query(sql, args...)
+	done := tx.statement(ctx, sql, args)
+	defer func() {
+		tx.touch(sql)
+		if err = done(err); err != nil {
+			tx.err = err
+			if result != nil {
+				result.Close()
+				result = nil
+			}
+		}
+	}()
+
+	result, err = tx.tx.QueryContext(ctx, sql, args...)
+	err = tx.queryError(sql, args, err)
 	tx.err = err
-	return result, tx.err
+	return result, err
 }
 
-func (tx *DBTx) Exec(sql string, args ...interface{}) (result sql.Result, err error) {
-	t1 := time.Now()
-	if tx.slowlog > 0 {
-		defer func(t time.Time) {
-			span := time.Now().Sub(t1)
-			if span > tx.slowlog {
-				log.Println("SLOW: ", span.String(), sql, args)
-			}
-		}(t1)
-	}
-	if tx.debug {
-		log.Println("DEBUG: ", sql, args)
-	}
-	if tx.ctx != nil {
-		result, err = tx.tx.ExecContext(tx.ctx, sql, args...)
+func (tx *DBTx) Exec(sql string, args ...interface{}) (sql.Result, error) {
+	return tx.exec(tx.context(), sql, args)
+}
+
+func (tx *DBTx) exec(ctx context.Context, sql string, args []interface{}) (result sql.Result, err error) {
+	if sql, err = tx.prepare(sql, args); err != nil {
 		tx.err = err
 		return
 	}
-	result, err = tx.tx.Exec(sql, args...)
+	done := tx.statement(ctx, sql, args)
+	defer func() {
+		tx.touch(sql)
+		if err == nil {
+			tx.observeRows(sql, result)
+		}
+		if err = done(err); err != nil {
+			tx.err = err
+		}
+	}()
+
+	result, err = tx.tx.ExecContext(ctx, sql, args...)
+	err = tx.queryError(sql, args, err)
 	tx.err = err
 	return
 }
 
+// UnwrapTx returns the underlying transaction for libraries that need a
+// *sql.Tx. Statements run on it skip validation, logging, metrics and hooks,
+// and their errors do not make Close roll back.
+func (tx *DBTx) UnwrapTx() *sql.Tx {
+	return tx.tx
+}
+
 func (tx *DBTx) SetError(err error) {
 	tx.err = err
 }
@@ -279,10 +490,53 @@ func (tx *DBTx) GetContext() context.Context {
 	return tx.ctx
 }
 
-func (db *TracedDB) Query(sql string, args ...interface{}) (*sql.Rows, error) {
-	span, _ := opentracing.StartSpanFromContext(db.ctx, "DB Query")
+func (tx *DBTx) context() context.Context {
+	if tx.ctx != nil {
+		return tx.ctx
+	}
+	return context.Background()
+}
+
+// OpenTraceSampled is OpenTrace tracing only a rate fraction of the
+// statements, between 0 and 1, unless the parent span in ctx is sampled.
+func OpenTraceSampled(ctx context.Context, db DB, rate float64) DB {
+	return &TracedDB{
+		DB:      db,
+		ctx:     ctx,
+		sampled: true,
+		rate:    rate,
+	}
+}
+
+// startSpan returns nil for a statement that is not sampled.
+func (db *TracedDB) startSpan(generic, sql string, args []interface{}) opentracing.Span {
+	if db.sampled && !parentSampled(db.ctx) && rand.Float64() >= db.rate {
+		return nil
+	}
+	span, _ := opentracing.StartSpanFromContext(db.ctx, spanName(generic, sql))
+	ottag.DBStatement.Set(span, sql)
 	span.LogFields(otlog.String("sql.query", fmt.Sprint(sql, ",", args)))
+	return span
+}
+
+// parentSampled reports whether the tracer flagged the span in ctx as
+// sampled, for tracers exposing it such as jaeger.
+func parentSampled(ctx context.Context) bool {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return false
+	}
+	sc, ok := parent.Context().(interface{ IsSampled() bool })
+	return ok && sc.IsSampled()
+}
+
+func (db *TracedDB) Query(sql string, args ...interface{}) (*sql.Rows, error) {
+	span := db.startSpan("DB Query", sql, args)
+	if span == nil {
+		return db.DB.Query(sql, args...)
+	}
 	defer span.Finish()
+	sql = traceComment(span) + sql
 	rows, err := db.DB.Query(sql, args...)
 	if err != nil {
 		logErrorToSpan(span, err)
@@ -291,9 +545,12 @@ func (db *TracedDB) Query(sql string, args ...interface{}) (*sql.Rows, error) {
 }
 
 func (db *TracedDB) Exec(sql string, args ...interface{}) (sql.Result, error) {
-	span, _ := opentracing.StartSpanFromContext(db.ctx, "DB Exec")
-	span.LogFields(otlog.String("sql.query", fmt.Sprint(sql, ",", args)))
+	span := db.startSpan("DB Exec", sql, args)
+	if span == nil {
+		return db.DB.Exec(sql, args...)
+	}
 	defer span.Finish()
+	sql = traceComment(span) + sql
 	result, err := db.DB.Exec(sql, args...)
 	if err != nil {
 		logErrorToSpan(span, err)
@@ -304,6 +561,41 @@ func (db *TracedDB) Exec(sql string, args ...interface{}) (sql.Result, error) {
 func (db *TracedDB) SetError(error)  {
 }
 
+// statement logs sql before it runs and returns the func to call with its
+// outcome, which reports slow statements, metrics and runs the hooks.
+func (o *options) statement(ctx context.Context, sql string, args []interface{}) func(err error) error {
+	if o.debug {
+		log.Println("DEBUG: ", sql, args)
+	}
+	if o.before != nil {
+		o.before(ctx, sql, args)
+	}
+	t1 := time.Now()
+	return func(err error) error {
+		span := time.Now().Sub(t1)
+		slow := o.slowlog > 0 && span > o.slowlog
+		if slow {
+			o.logSlow(span, sql, args)
+			if o.slowEvents != nil {
+				o.slowEvents.publish(sql, args, span)
+			}
+		}
+		if err != nil && o.logOnError {
+			o.logError(sql, args, err)
+		}
+		if o.metrics != nil {
+			o.metrics.ObserveStatement(Fingerprint(sql), span, err)
+		}
+		if o.after != nil {
+			o.after(ctx, sql, args, err, span)
+		}
+		if slow && err == nil && o.slowAsError {
+			return &SlowQueryError{SQL: sql, Duration: span, Threshold: o.slowlog}
+		}
+		return err
+	}
+}
+
 func logErrorToSpan(span opentracing.Span, err error) {
 	ottag.Error.Set(span, true)
 	span.LogFields(otlog.Error(err))
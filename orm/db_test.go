@@ -0,0 +1,29 @@
+package orm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloneSettings(t *testing.T) {
+	store := fakeStore("SELECT 1", nil)
+	store.TrackTransactions(1, time.Hour)
+	store.SetSlowLogSampling(time.Minute)
+	c := store.AllowFullTableUpdate()
+	if c.txTracker != store.txTracker || c.slowSampler == nil || c.slowSampler == store.slowSampler {
+		t.Fatalf("expected clone sharing the tracker with its own sampler")
+	}
+	c.Close()
+	c.TrackTransactions(0, 0)
+	if store.txTracker == nil {
+		t.Fatalf("expected clone to leave the tracker of store")
+	}
+	select {
+	case <-store.txTracker.stop:
+		t.Fatalf("expected tracker of store to keep running")
+	default:
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,142 @@
+package orm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Bound parameter limits of the server protocols.
+const (
+	mysqlMaxPlaceholders = 65535
+	mssqlMaxPlaceholders = 2100
+)
+
+func maxPlaceholders(driver string) int {
+	if driver == "mssql" {
+		return mssqlMaxPlaceholders
+	}
+	return mysqlMaxPlaceholders
+}
+
+var safeIdent = regexp.MustCompile(`^[A-Za-z0-9_]+(?:\.[A-Za-z0-9_]+)*$`)
+
+// checkIdents rejects identifiers other than dot separated words of
+// [A-Za-z0-9_], so that config driven names can't smuggle SQL past
+// quoteIdent.
+func checkIdents(idents ...string) error {
+	for _, ident := range idents {
+		if !safeIdent.MatchString(ident) {
+			return fmt.Errorf("invalid identifier: %q", ident)
+		}
+	}
+	return nil
+}
+
+func checkColumns(table string, cols map[string]interface{}) error {
+	if err := checkIdents(table); err != nil {
+		return err
+	}
+	for col := range cols {
+		if err := checkIdents(col); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteIdent quotes a possibly schema qualified identifier for driver.
+func quoteIdent(driver, ident string) string {
+	parts := strings.Split(ident, ".")
+	for i, part := range parts {
+		if driver == "mssql" {
+			parts[i] = "[" + strings.Replace(part, "]", "]]", -1) + "]"
+		} else {
+			parts[i] = "`" + strings.Replace(part, "`", "``", -1) + "`"
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+var (
+	mssqlFromTable = regexp.MustCompile(`(?i)\bFROM\s+([^\s,()]+)(?:\s+(?:AS\s+)?([A-Za-z_][A-Za-z0-9_]*))?`)
+	sqlKeywords    = NewStringSet("WHERE", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "OUTER",
+		"ON", "ORDER", "GROUP", "HAVING", "UNION", "OPTION", "WITH")
+)
+
+// forUpdate adds the dialect's row locking clause to a SELECT: FOR UPDATE
+// for mysql and an UPDLOCK table hint on the first table for mssql.
+func forUpdate(driver, query string) (string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if driver != "mssql" {
+		return query + " FOR UPDATE", nil
+	}
+	loc := mssqlFromTable.FindStringSubmatchIndex(query)
+	if loc == nil {
+		return "", fmt.Errorf("no table to lock in: %s", query)
+	}
+	end := loc[1]
+	if loc[4] >= 0 && sqlKeywords.Contains(strings.ToUpper(query[loc[4]:loc[5]])) {
+		end = loc[3]
+	}
+	return query[:end] + " WITH (UPDLOCK, ROWLOCK)" + query[end:], nil
+}
+
+// indexHint makes every FROM or JOIN reference of table use index, adding
+// USE INDEX for mysql or an INDEX table hint for mssql after any alias.
+func indexHint(driver, query, table, index string) (string, error) {
+	ref := regexp.MustCompile(`(?i)\b(?:FROM|JOIN)\s+(` + regexp.QuoteMeta(table) + `)(?:\s+(?:AS\s+)?([A-Za-z_][A-Za-z0-9_]*))?`)
+	hint := " USE INDEX (" + quoteIdent(driver, index) + ")"
+	if driver == "mssql" {
+		hint = " WITH (INDEX(" + quoteIdent(driver, index) + "))"
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range ref.FindAllStringSubmatchIndex(query, -1) {
+		if loc[3] < len(query) && isIdentRune(rune(query[loc[3]])) {
+			continue
+		}
+		end := loc[1]
+		if loc[4] >= 0 && sqlKeywords.Contains(strings.ToUpper(query[loc[4]:loc[5]])) {
+			end = loc[3]
+		}
+		b.WriteString(query[last:end])
+		b.WriteString(hint)
+		last = end
+	}
+	if last == 0 {
+		return "", fmt.Errorf("table %s not found in: %s", table, query)
+	}
+	b.WriteString(query[last:])
+	return b.String(), nil
+}
+
+var leadingKeyword = regexp.MustCompile(`(?i)^\s*(SELECT|INSERT|REPLACE|UPDATE|DELETE)\b`)
+
+// queryHint adds a statement level hint: OPTION (hint) for mssql and an
+// optimizer hint comment after the leading keyword for mysql.
+func queryHint(driver, query, hint string) (string, error) {
+	if strings.Contains(hint, "*/") {
+		return "", fmt.Errorf("invalid hint: %s", hint)
+	}
+	if driver == "mssql" {
+		return strings.TrimRight(strings.TrimSpace(query), ";") + " OPTION (" + hint + ")", nil
+	}
+	loc := leadingKeyword.FindStringSubmatchIndex(query)
+	if loc == nil {
+		return "", fmt.Errorf("no statement keyword for hint in: %s", query)
+	}
+	return query[:loc[1]] + " /*+ " + hint + " */" + query[loc[1]:], nil
+}
+
+// WithIndexHint makes every FROM or JOIN reference of table in query use
+// index, in the dialect of the store's driver.
+func (store *DBStore) WithIndexHint(query, table, index string) (string, error) {
+	return indexHint(store.driver, query, table, index)
+}
+
+// WithQueryHint adds a statement level hint to query, e.g. RECOMPILE for
+// mssql or MAX_EXECUTION_TIME(1000) for mysql.
+func (store *DBStore) WithQueryHint(query, hint string) (string, error) {
+	return queryHint(store.driver, query, hint)
+}
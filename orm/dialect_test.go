@@ -0,0 +1,52 @@
+package orm
+
+import "testing"
+
+func TestIndexHint(t *testing.T) {
+	cases := []struct {
+		driver, sql, out string
+	}{
+		{"mysql", "SELECT * FROM orders o WHERE o.id = ?", "SELECT * FROM orders o USE INDEX (`idx_user`) WHERE o.id = ?"},
+		{"mysql", "SELECT * FROM users u JOIN orders WHERE u.id = ?", "SELECT * FROM users u JOIN orders USE INDEX (`idx_user`) WHERE u.id = ?"},
+		{"mysql", "SELECT * FROM orders_archive", ""},
+		{"mssql", "SELECT * FROM orders AS o", "SELECT * FROM orders AS o WITH (INDEX([idx_user]))"},
+	}
+	for i, c := range cases {
+		out, err := indexHint(c.driver, c.sql, "orders", "idx_user")
+		if c.out == "" && err == nil || c.out != "" && out != c.out {
+			t.Errorf("#%d expected %q, got %q (%v)", i+1, c.out, out, err)
+		}
+	}
+}
+
+func TestQueryHint(t *testing.T) {
+	if out, _ := queryHint("mssql", "SELECT * FROM t;", "RECOMPILE"); out != "SELECT * FROM t OPTION (RECOMPILE)" {
+		t.Errorf("got %q", out)
+	}
+	if out, _ := queryHint("mysql", "SELECT * FROM t", "MAX_EXECUTION_TIME(1000)"); out != "SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t" {
+		t.Errorf("got %q", out)
+	}
+}
+
+func TestCheckIdents(t *testing.T) {
+	cases := []struct {
+		ident string
+		ok    bool
+	}{
+		{"users", true},
+		{"dbo.Order_2", true},
+		{"", false},
+		{"a b", false},
+		{"users;DROP", false},
+		{"a`b", false},
+		{"a--", false},
+		{"a/*b*/", false},
+		{".users", false},
+		{"a..b", false},
+	}
+	for i, c := range cases {
+		if err := checkIdents(c.ident); (err == nil) != c.ok {
+			t.Errorf("#%d expected ok %v for %q, got %v", i+1, c.ok, c.ident, err)
+		}
+	}
+}
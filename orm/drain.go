@@ -0,0 +1,57 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDraining is returned for new statements and transactions once Drain
+// has been called.
+var ErrDraining = errors.New("store is draining")
+
+// activity counts the statements and transactions in flight on a store and
+// its clones.
+type activity struct {
+	draining int32
+	active   int64
+}
+
+func (a *activity) enter() error {
+	if a == nil {
+		return nil
+	}
+	atomic.AddInt64(&a.active, 1)
+	if atomic.LoadInt32(&a.draining) == 1 {
+		a.leave()
+		return ErrDraining
+	}
+	return nil
+}
+
+func (a *activity) leave() {
+	if a != nil {
+		atomic.AddInt64(&a.active, -1)
+	}
+}
+
+// Drain quiesces the store for shutdown: new statements and transactions
+// fail with ErrDraining, those in flight and open transactions are waited
+// for, then the store is closed. When ctx expires first the remaining
+// connections are closed underneath them and ctx's error is returned.
+func (store *DBStore) Drain(ctx context.Context) error {
+	if a := store.activity; a != nil {
+		atomic.StoreInt32(&a.draining, 1)
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for atomic.LoadInt64(&a.active) > 0 {
+			select {
+			case <-ctx.Done():
+				return store.CloseContext(ctx)
+			case <-ticker.C:
+			}
+		}
+	}
+	return store.CloseContext(ctx)
+}
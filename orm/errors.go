@@ -0,0 +1,72 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// QueryError is a failed Query or Exec with its statement. errors.Is and
+// errors.As reach the driver error through Unwrap.
+type QueryError struct {
+	SQL  string
+	Args []interface{}
+	Err  error
+}
+
+func (e *QueryError) Error() string {
+	if e.Args == nil {
+		return fmt.Sprintf("%v [%s]", e.Err, e.SQL)
+	}
+	return fmt.Sprintf("%v [%s %v]", e.Err, e.SQL, e.Args)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// RedactErrorArgs leaves the args out of QueryError, for args that must
+// not end up in logs.
+func (store *DBStore) RedactErrorArgs(b bool) {
+	store.redactArgs = b
+}
+
+// SetLogOnError logs failed statements with their args at WARN, a cheap
+// alternative to Debug for production.
+func (store *DBStore) SetLogOnError(b bool) {
+	store.logOnError = b
+}
+
+func (o *options) logError(sql string, args []interface{}, err error) {
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		err = o.queryError(sql, args, err)
+	}
+	log.Println("WARN: ", err)
+}
+
+func (o *options) queryError(sql string, args []interface{}, err error) error {
+	if err == nil {
+		return nil
+	}
+	if o.redactArgs {
+		args = nil
+	}
+	return &QueryError{SQL: sql, Args: args, Err: err}
+}
+
+// ScanError is a column that could not be scanned into its struct field.
+type ScanError struct {
+	Column string
+	Field  string
+	Type   string
+	Err    error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("scan column %s into field %s (%s): %v", e.Column, e.Field, e.Type, e.Err)
+}
+
+func (e *ScanError) Unwrap() error {
+	return e.Err
+}
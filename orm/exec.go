@@ -0,0 +1,37 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// ExecAffected runs ExecContext and returns the number of rows affected.
+func (store *DBStore) ExecAffected(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	result, err := store.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ExecInsertID runs ExecContext and returns the id of the inserted row.
+func (store *DBStore) ExecInsertID(ctx context.Context, sql string, args ...interface{}) (int64, error) {
+	result, err := store.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ExecDDL runs a schema statement without args. DDL is expected to be slow,
+// so it is logged up front and never reported by the slow log.
+func (store *DBStore) ExecDDL(ctx context.Context, ddl string) (sql.Result, error) {
+	if err := store.checkWritable(); err != nil {
+		return nil, err
+	}
+	log.Println("INFO: ", ddl)
+	c := store.Clone()
+	c.slowlog = 0
+	return c.ExecContext(ctx, ddl)
+}
@@ -0,0 +1,98 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// TestingT is the part of *testing.T AssertUsesIndex reports to.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// AssertUsesIndex fails t unless the plan of query uses index, to lock in
+// the plans of critical queries in CI.
+func (store *DBStore) AssertUsesIndex(t TestingT, query, index string, args ...interface{}) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	indexes, err := store.PlanIndexes(store.context(), query, args...)
+	if err != nil {
+		t.Errorf("explain %s: %v", query, err)
+		return false
+	}
+	for _, idx := range indexes {
+		if idx == index {
+			return true
+		}
+	}
+	t.Errorf("expected plan to use index %s, got %v: %s", index, indexes, query)
+	return false
+}
+
+// PlanIndexes returns the indexes in the execution plan of query: the key
+// column of EXPLAIN for mysql and the Index attributes of the SHOWPLAN_XML
+// plan for mssql.
+func (store *DBStore) PlanIndexes(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	switch store.driver {
+	case "mysql":
+		rows, err := store.QueryContext(ctx, "EXPLAIN "+query, args...)
+		if err != nil {
+			return nil, err
+		}
+		plan, err := RowsToMaps(rows)
+		if err != nil {
+			return nil, err
+		}
+		indexes := []string{}
+		for _, row := range plan {
+			if key, ok := row["key"].([]byte); ok {
+				indexes = append(indexes, string(key))
+			}
+		}
+		return indexes, nil
+	case "mssql":
+		c, err := store.pin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+		if _, err := c.conn.ExecContext(ctx, "SET SHOWPLAN_XML ON"); err != nil {
+			return nil, err
+		}
+		c.release = func(ctx context.Context) error {
+			_, err := c.conn.ExecContext(ctx, "SET SHOWPLAN_XML OFF")
+			return err
+		}
+		query, err := c.prepare(query, args)
+		if err != nil {
+			return nil, err
+		}
+		var plan sql.NullString
+		if err := c.conn.QueryRowContext(ctx, query, args...).Scan(&plan); err != nil {
+			return nil, err
+		}
+		return showplanIndexes(plan.String), nil
+	}
+	return nil, fmt.Errorf("unsupport db driver: %s", store.driver)
+}
+
+// showplanIndexes collects the Index="[name]" attributes of a plan.
+func showplanIndexes(plan string) []string {
+	indexes := []string{}
+	for {
+		i := strings.Index(plan, ` Index="[`)
+		if i < 0 {
+			return indexes
+		}
+		plan = plan[i+len(` Index="[`):]
+		end := strings.Index(plan, `]"`)
+		if end < 0 {
+			return indexes
+		}
+		indexes = append(indexes, plan[:end])
+		plan = plan[end:]
+	}
+}
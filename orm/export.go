@@ -0,0 +1,153 @@
+package orm
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// QueryJSON streams the result of sql to w as a JSON array of row objects
+// keyed by column name in column order, encoding one row at a time so
+// large exports keep memory flat. Byte values are written as strings.
+func (store *DBStore) QueryJSON(ctx context.Context, w io.Writer, sql string, args ...interface{}) error {
+	return store.queryJSON(ctx, w, false, sql, args)
+}
+
+// QueryJSONTyped is QueryJSON writing {"columns": [...], "rows": [...]},
+// the columns described by ColumnInfo and numeric columns written as JSON
+// numbers instead of strings.
+func (store *DBStore) QueryJSONTyped(ctx context.Context, w io.Writer, sql string, args ...interface{}) error {
+	return store.queryJSON(ctx, w, true, sql, args)
+}
+
+func (store *DBStore) queryJSON(ctx context.Context, w io.Writer, typed bool, sql string, args []interface{}) error {
+	rows, err := store.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	var infos []ColumnInfo
+	if typed {
+		if infos, err = ColumnInfos(rows); err != nil {
+			return err
+		}
+	}
+	keys := make([][]byte, len(columns))
+	for i, col := range columns {
+		if keys[i], err = json.Marshal(col); err != nil {
+			return err
+		}
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	bw := bufio.NewWriter(w)
+	if typed {
+		header, err := json.Marshal(infos)
+		if err != nil {
+			return err
+		}
+		bw.WriteString(`{"columns":`)
+		bw.Write(header)
+		bw.WriteString(`,"rows":`)
+	}
+	bw.WriteByte('[')
+	limit := store.rowLimit(ctx)
+	for n := 0; rows.Next(); n++ {
+		if err := limit.add(); err != nil {
+			return err
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if n > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteByte('{')
+		for i, v := range values {
+			if typed {
+				v = infos[i].convert(v)
+			} else if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.Write(keys[i])
+			bw.WriteByte(':')
+			bw.Write(value)
+		}
+		if err := bw.WriteByte('}'); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	bw.WriteByte(']')
+	if typed {
+		bw.WriteByte('}')
+	}
+	return bw.Flush()
+}
+
+// WriteCSV writes a header of the column names followed by every row of
+// rows as CSV: []byte as text, time.Time in RFC3339 and NULL as empty.
+// rows is always closed.
+func WriteCSV(w io.Writer, rows *sql.Rows) error {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	record := make([]string, len(columns))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+				record[i] = ""
+			case []byte:
+				record[i] = string(v)
+			case time.Time:
+				record[i] = v.Format(time.RFC3339)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,148 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// failoverProbeInterval is how often a failed over store pings its primary.
+const failoverProbeInterval = 5 * time.Second
+
+// FailoverStore runs every statement on the active one of two stores for
+// active/passive setups. It starts on the primary, fails over to the
+// secondary when the primary can not be reached and fails back once a
+// probe of the primary succeeds again.
+type FailoverStore struct {
+	primary   *DBStore
+	secondary *DBStore
+	// onSecondary is 1 while failed over.
+	onSecondary int32
+	stop        chan struct{}
+}
+
+var _ ContextDB = &FailoverStore{}
+
+// NewDBStoreFailover opens a store for each of primary and secondary.
+func NewDBStoreFailover(primary, secondary *DBConfig) (*FailoverStore, error) {
+	p, err := NewDBStoreConfig(primary)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewDBStoreConfig(secondary)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	f := &FailoverStore{primary: p, secondary: s, stop: make(chan struct{})}
+	go f.probe()
+	return f, nil
+}
+
+// Primary and Secondary return the underlying stores, e.g. to configure
+// them.
+func (f *FailoverStore) Primary() *DBStore {
+	return f.primary
+}
+
+func (f *FailoverStore) Secondary() *DBStore {
+	return f.secondary
+}
+
+// Active returns the store statements currently run on.
+func (f *FailoverStore) Active() *DBStore {
+	if atomic.LoadInt32(&f.onSecondary) == 1 {
+		return f.secondary
+	}
+	return f.primary
+}
+
+// unreachable reports whether err means no connection could be made, so
+// that the statement never reached the server and can run elsewhere.
+func unreachable(err error) bool {
+	var op *net.OpError
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &op) && op.Op == "dial"
+}
+
+// run runs fn on the active store, failing over and running it again on
+// the secondary if the primary is unreachable.
+func (f *FailoverStore) run(fn func(store *DBStore) error) error {
+	store := f.Active()
+	err := fn(store)
+	if err == nil || store != f.primary || !unreachable(err) {
+		return err
+	}
+	if atomic.CompareAndSwapInt32(&f.onSecondary, 0, 1) {
+		log.Println("WARN: ", "primary unreachable, failing over to secondary:", err)
+	}
+	return fn(f.secondary)
+}
+
+func (f *FailoverStore) probe() {
+	t := time.NewTicker(failoverProbeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-t.C:
+		}
+		if atomic.LoadInt32(&f.onSecondary) == 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(f.primary.context(), failoverProbeInterval)
+		err := f.primary.PingContext(ctx)
+		cancel()
+		if err == nil && atomic.CompareAndSwapInt32(&f.onSecondary, 1, 0) {
+			log.Println("INFO: ", "primary reachable again, failing back")
+		}
+	}
+}
+
+func (f *FailoverStore) Query(sql string, args ...interface{}) (*sql.Rows, error) {
+	return f.QueryContext(f.Active().context(), sql, args...)
+}
+
+func (f *FailoverStore) QueryContext(ctx context.Context, sql string, args ...interface{}) (rows *sql.Rows, err error) {
+	err = f.run(func(store *DBStore) error {
+		rows, err = store.QueryContext(ctx, sql, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (f *FailoverStore) Exec(sql string, args ...interface{}) (sql.Result, error) {
+	return f.ExecContext(f.Active().context(), sql, args...)
+}
+
+func (f *FailoverStore) ExecContext(ctx context.Context, sql string, args ...interface{}) (result sql.Result, err error) {
+	err = f.run(func(store *DBStore) error {
+		result, err = store.ExecContext(ctx, sql, args...)
+		return err
+	})
+	return result, err
+}
+
+func (f *FailoverStore) SetError(err error) {}
+
+func (f *FailoverStore) BeginTx(ctx context.Context) (tx TX, err error) {
+	err = f.run(func(store *DBStore) error {
+		tx, err = store.BeginTx(ctx)
+		return err
+	})
+	return tx, err
+}
+
+func (f *FailoverStore) Close() error {
+	close(f.stop)
+	err := f.primary.Close()
+	if serr := f.secondary.Close(); err == nil {
+		err = serr
+	}
+	return err
+}
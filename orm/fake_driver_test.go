@@ -0,0 +1,93 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+)
+
+// fakeDriver serves canned results keyed by query, so row helpers can be
+// tested without a database.
+type fakeDriver struct {
+	mu      sync.Mutex
+	results map[string]*fakeResult
+}
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+var _fakeDriver = &fakeDriver{results: map[string]*fakeResult{}}
+
+func init() {
+	sql.Register("orm_fake", _fakeDriver)
+}
+
+func fakeStore(query string, columns []string, rows ...[]driver.Value) *DBStore {
+	_fakeDriver.mu.Lock()
+	_fakeDriver.results[query] = &fakeResult{columns: columns, rows: rows}
+	_fakeDriver.mu.Unlock()
+	db, err := sql.Open("orm_fake", "")
+	if err != nil {
+		panic(err)
+	}
+	return &DBStore{DB: db}
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{c.d, query}, nil
+}
+
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c fakeConn) Commit() error             { return nil }
+func (c fakeConn) Rollback() error           { return nil }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	result := s.d.results[s.query]
+	if result == nil {
+		result = &fakeResult{}
+	}
+	return &fakeRows{result: result}, nil
+}
+
+type fakeRows struct {
+	result *fakeResult
+	pos    int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.pos])
+	r.pos++
+	return nil
+}
@@ -0,0 +1,78 @@
+package orm
+
+import (
+	"container/list"
+	"sync"
+)
+
+const (
+	defaultFingerprintCacheSize = 1024
+	// fingerprintCacheMaxLen keeps huge one-off statements such as bulk
+	// inserts from pinning memory in the cache.
+	fingerprintCacheMaxLen = 8192
+)
+
+var _fingerprints = newFingerprintCache(defaultFingerprintCacheSize)
+
+// fingerprintCache is an LRU of statements to their fingerprints, so that
+// the metrics path doesn't normalize the same statement on every call.
+type fingerprintCache struct {
+	mu    sync.Mutex
+	size  int
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+type fingerprintEntry struct {
+	query, fingerprint string
+}
+
+func newFingerprintCache(size int) *fingerprintCache {
+	return &fingerprintCache{size: size, lru: list.New(), items: map[string]*list.Element{}}
+}
+
+// SetFingerprintCacheSize sets how many statements Fingerprint remembers,
+// 1024 by default. Zero disables the cache.
+func SetFingerprintCacheSize(n int) {
+	_fingerprints.mu.Lock()
+	defer _fingerprints.mu.Unlock()
+	_fingerprints.size = n
+	for _fingerprints.lru.Len() > n && _fingerprints.lru.Len() > 0 {
+		_fingerprints.evict()
+	}
+}
+
+func (c *fingerprintCache) get(query string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[query]
+	if !ok {
+		return "", false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*fingerprintEntry).fingerprint, true
+}
+
+func (c *fingerprintCache) add(query, fingerprint string) {
+	if len(query) > fingerprintCacheMaxLen {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size <= 0 {
+		return
+	}
+	if _, ok := c.items[query]; ok {
+		return
+	}
+	c.items[query] = c.lru.PushFront(&fingerprintEntry{query: query, fingerprint: fingerprint})
+	for c.lru.Len() > c.size {
+		c.evict()
+	}
+}
+
+func (c *fingerprintCache) evict() {
+	e := c.lru.Back()
+	c.lru.Remove(e)
+	delete(c.items, e.Value.(*fingerprintEntry).query)
+}
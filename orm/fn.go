@@ -147,6 +147,26 @@ func SQLWhere(conditions []string) string {
 	return ""
 }
 
+// InTuples builds a row constructor condition (a, b) IN ((?,?),(?,?)) over
+// cols with the tuple values flattened into args. Each tuple must hold one
+// value per column. An empty tuples list yields a condition matching no
+// rows. mssql has no row constructors.
+func InTuples(cols []string, tuples [][]interface{}) (clause string, args []interface{}) {
+	if len(tuples) == 0 {
+		return "1 = 0", nil
+	}
+	group := "(" + strings.Join(NewStringSlice(len(cols), "?"), ",") + ")"
+	args = make([]interface{}, 0, len(cols)*len(tuples))
+	for _, tuple := range tuples {
+		if len(tuple) != len(cols) {
+			panic(fmt.Sprintf("InTuples: tuple has %d values for %d columns", len(tuple), len(cols)))
+		}
+		args = append(args, tuple...)
+	}
+	clause = fmt.Sprintf("(%s) IN (%s)", strings.Join(cols, ", "), strings.Join(NewStringSlice(len(tuples), group), ","))
+	return clause, args
+}
+
 func SQLOrderBy(field string, revert bool) string {
 	if field != "" {
 		if revert {
@@ -0,0 +1,19 @@
+package orm
+
+import (
+	"context"
+	"time"
+)
+
+// BeforeHook runs before every statement of a DBStore and its transactions.
+type BeforeHook func(ctx context.Context, sql string, args []interface{})
+
+// AfterHook runs once a statement returned, with its error and duration.
+type AfterHook func(ctx context.Context, sql string, args []interface{}, err error, d time.Duration)
+
+// SetHooks installs the statement hooks, either of which may be nil.
+// Transactions pick up the hooks set when they begin.
+func (store *DBStore) SetHooks(before BeforeHook, after AfterHook) {
+	store.before = before
+	store.after = after
+}
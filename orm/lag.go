@@ -0,0 +1,110 @@
+package orm
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+)
+
+// SetMaxReplicaLag checks the replication lag of every replica each
+// interval and takes those more than threshold behind, or whose lag is
+// unknown, out of the read rotation until they catch up. Reads go to the
+// primary while no replica qualifies. Only mysql replicas are checked. A
+// zero threshold stops checking; a non-positive interval checks every 5s.
+func (c *ClusterStore) SetMaxReplicaLag(threshold, interval time.Duration) {
+	c.lagMu.Lock()
+	defer c.lagMu.Unlock()
+	if c.stopLag != nil {
+		close(c.stopLag)
+		c.stopLag = nil
+	}
+	c.maxLag = threshold
+	c.lags = make([]time.Duration, len(c.replicas))
+	c.lagging = make([]bool, len(c.replicas))
+	if threshold <= 0 || len(c.replicas) == 0 {
+		return
+	}
+	if interval <= 0 {
+		interval = defaultLagInterval
+	}
+	stop := make(chan struct{})
+	c.stopLag = stop
+	go c.checkLag(interval, stop)
+}
+
+const defaultLagInterval = 5 * time.Second
+
+// MaxReplicaLag returns the threshold set by SetMaxReplicaLag.
+func (c *ClusterStore) MaxReplicaLag() time.Duration {
+	c.lagMu.RLock()
+	defer c.lagMu.RUnlock()
+	return c.maxLag
+}
+
+// ReplicaLag returns the last measured lag of each replica in the order
+// given to NewClusterStore, -1 if unknown.
+func (c *ClusterStore) ReplicaLag() []time.Duration {
+	c.lagMu.RLock()
+	defer c.lagMu.RUnlock()
+	return append([]time.Duration{}, c.lags...)
+}
+
+func (c *ClusterStore) checkLag(interval time.Duration, stop chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		c.updateLag(stop)
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (c *ClusterStore) updateLag(stop chan struct{}) {
+	lags := make([]time.Duration, len(c.replicas))
+	for i, r := range c.replicas {
+		lags[i] = replicaLag(r)
+	}
+	c.lagMu.Lock()
+	defer c.lagMu.Unlock()
+	if c.stopLag != stop {
+		return
+	}
+	for i, lag := range lags {
+		lagging := lag < 0 || lag > c.maxLag
+		if lagging != c.lagging[i] {
+			log.Println("INFO: ", "replica", i, "lag", lag.String(), "in rotation", !lagging)
+		}
+		c.lags[i], c.lagging[i] = lag, lagging
+	}
+}
+
+// replicaLag reads Seconds_Behind_Master of a mysql replica, returning -1
+// if it is unknown, e.g. because replication is stopped.
+func replicaLag(r *DBStore) time.Duration {
+	if r.driver != "mysql" {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(r.context(), 5*time.Second)
+	defer cancel()
+	rows, err := r.QueryContext(ctx, "SHOW SLAVE STATUS")
+	if err != nil {
+		return -1
+	}
+	status, err := RowsToMaps(rows)
+	if err != nil || len(status) == 0 {
+		return -1
+	}
+	b, ok := status[0]["Seconds_Behind_Master"].([]byte)
+	if !ok {
+		return -1
+	}
+	secs, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return time.Duration(secs) * time.Second
+}
@@ -0,0 +1,87 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrNotInTransaction = errors.New("statement requires a transaction")
+
+// QueryForUpdate runs a SELECT with the driver's row locking clause
+// appended, holding the selected rows until the transaction ends.
+func (tx *DBTx) QueryForUpdate(query string, args ...interface{}) (*sql.Rows, error) {
+	if tx.tx == nil {
+		return nil, ErrNotInTransaction
+	}
+	locked, err := forUpdate(tx.driver, query)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Query(locked, args...)
+}
+
+// QueryForUpdate is DBTx.QueryForUpdate for code holding a DB, failing with
+// ErrNotInTransaction unless db is a transaction.
+func QueryForUpdate(db DB, query string, args ...interface{}) (*sql.Rows, error) {
+	switch db := db.(type) {
+	case *DBTx:
+		return db.QueryForUpdate(query, args...)
+	case *TracedDB:
+		return QueryForUpdate(db.DB, query, args...)
+	}
+	return nil, ErrNotInTransaction
+}
+
+// TableLock is a table for LockTables, locked for reading unless Write.
+type TableLock struct {
+	Table string
+	Write bool
+}
+
+// LockTables pins a connection holding LOCK TABLES on locks, for online
+// schema changes that need the lock across statements; a lock taken through
+// the pool would be released as soon as its connection went back. The
+// returned ConnDB must be used for the statements under the lock, and its
+// Close runs UNLOCK TABLES, discarding the connection if that fails. Only
+// mysql is supported.
+func (store *DBStore) LockTables(ctx context.Context, locks ...TableLock) (*ConnDB, error) {
+	if store.driver != "mysql" {
+		return nil, fmt.Errorf("unsupport db driver: %s", store.driver)
+	}
+	if len(locks) == 0 {
+		return nil, errors.New("no tables to lock")
+	}
+	clauses := make([]string, len(locks))
+	for i, l := range locks {
+		if err := checkIdents(l.Table); err != nil {
+			return nil, err
+		}
+		mode := "READ"
+		if l.Write {
+			if err := store.checkWritable(); err != nil {
+				return nil, err
+			}
+			mode = "WRITE"
+		}
+		clauses[i] = quoteIdent(store.driver, l.Table) + " " + mode
+	}
+	if ctx == nil {
+		ctx = store.context()
+	}
+	c, err := store.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.Exec("LOCK TABLES " + strings.Join(clauses, ", ")); err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.release = func(ctx context.Context) error {
+		_, err := c.conn.ExecContext(ctx, "UNLOCK TABLES")
+		return err
+	}
+	return c, nil
+}
@@ -0,0 +1,54 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTooManyRows is returned, wrapped, by the row reading helpers once a
+// result exceeds the row limit, see SetMaxRows.
+var ErrTooManyRows = errors.New("too many rows")
+
+type maxRowsKey struct{}
+
+// WithMaxRows sets the row limit of the helpers called with the returned
+// context, overriding the store default; 0 disables the limit.
+func WithMaxRows(ctx context.Context, n int64) context.Context {
+	return context.WithValue(ctx, maxRowsKey{}, n)
+}
+
+// SetMaxRows makes QueryEach, ForEachBatch, QueryJSON, QueryJSONTyped,
+// QueryCached, QueryParallel, Stream and QueryColumn abort with
+// ErrTooManyRows after reading n rows, a safety net against unbounded reads
+// such as a missing WHERE. Zero disables the limit. The helpers taking
+// *sql.Rows have Max variants with a limit per call instead.
+func (store *DBStore) SetMaxRows(n int64) {
+	store.maxRows = n
+}
+
+// rowCounter counts the rows read against a limit, 0 for none.
+type rowCounter struct {
+	max, n int64
+}
+
+func (c *rowCounter) add() error {
+	c.n++
+	if c.max > 0 && c.n > c.max {
+		return fmt.Errorf("%w: result exceeds %d rows", ErrTooManyRows, c.max)
+	}
+	return nil
+}
+
+func ctxMaxRows(ctx context.Context, def int64) int64 {
+	if ctx != nil {
+		if n, ok := ctx.Value(maxRowsKey{}).(int64); ok {
+			return n
+		}
+	}
+	return def
+}
+
+func (o *options) rowLimit(ctx context.Context) *rowCounter {
+	return &rowCounter{max: ctxMaxRows(ctx, o.maxRows)}
+}
@@ -0,0 +1,34 @@
+package orm
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Metrics receives one observation per statement run through a DBStore or
+// its transactions. Statements are labelled by Fingerprint to keep the
+// label cardinality low.
+type Metrics interface {
+	ObserveStatement(fingerprint string, d time.Duration, err error)
+}
+
+func (store *DBStore) SetMetrics(m Metrics) {
+	store.metrics = m
+}
+
+// RowsMetrics is optionally implemented by a Metrics to also receive the
+// rows affected by Exec and the rows read by QueryEach, catching runaway
+// result sets that latency alone hides.
+type RowsMetrics interface {
+	ObserveRows(fingerprint string, rows int64)
+}
+
+func (o *options) observeRows(sql string, result sql.Result) {
+	m, ok := o.metrics.(RowsMetrics)
+	if !ok || result == nil {
+		return
+	}
+	if n, err := result.RowsAffected(); err == nil {
+		m.ObserveRows(Fingerprint(sql), n)
+	}
+}
@@ -0,0 +1,67 @@
+package orm
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MultiStore is a registry of named stores, possibly of different drivers,
+// for services that talk to several databases.
+type MultiStore struct {
+	mu     sync.RWMutex
+	stores map[string]*DBStore
+}
+
+func NewMultiStore() *MultiStore {
+	return &MultiStore{stores: map[string]*DBStore{}}
+}
+
+// Add registers store under name, replacing any store of that name.
+func (ms *MultiStore) Add(name string, store *DBStore) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.stores[name] = store
+}
+
+// Lookup returns the store registered under name.
+func (ms *MultiStore) Lookup(name string) (*DBStore, bool) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	store, ok := ms.stores[name]
+	return store, ok
+}
+
+// On returns the store registered under name and panics if there is none,
+// the names being fixed at setup.
+func (ms *MultiStore) On(name string) *DBStore {
+	store, ok := ms.Lookup(name)
+	if !ok {
+		panic(fmt.Sprintf("orm: no store named %q", name))
+	}
+	return store
+}
+
+// Names returns the registered names in sorted order.
+func (ms *MultiStore) Names() []string {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	names := make([]string, 0, len(ms.stores))
+	for name := range ms.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Close closes every registered store, returning the first error.
+func (ms *MultiStore) Close() error {
+	var err error
+	for _, name := range ms.Names() {
+		store, _ := ms.Lookup(name)
+		if cerr := store.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// NamedExec runs sql with its :name parameters bound to the fields of the
+// struct arg by db tag, as ScanStruct matches columns. Embedded structs
+// are searched too and nil pointer fields bind NULL.
+func (store *DBStore) NamedExec(ctx context.Context, sql string, arg interface{}) (sql.Result, error) {
+	query, names, err := compileNamed(sql)
+	if err != nil {
+		return nil, err
+	}
+	args, err := namedArgs(names, arg)
+	if err != nil {
+		return nil, err
+	}
+	return store.ExecContext(ctx, query, args...)
+}
+
+// compileNamed replaces the :name parameters of query outside of string
+// literals, quoted identifiers and comments with ? and returns the names in
+// order. :: and := are left alone.
+func compileNamed(query string) (string, []string, error) {
+	var b strings.Builder
+	names := []string{}
+	last := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == ':':
+			if i+1 < len(query) && (query[i+1] == ':' || query[i+1] == '=') {
+				i++
+				continue
+			}
+			j := i + 1
+			for j < len(query) && isIdentRune(rune(query[j])) {
+				j++
+			}
+			if j == i+1 {
+				continue
+			}
+			names = append(names, query[i+1:j])
+			b.WriteString(query[last:i])
+			b.WriteByte('?')
+			last = j
+			i = j - 1
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			start := i
+			for i++; i < len(query) && query[i] != end; i++ {
+				if query[i] == '\\' && c != '`' && c != '[' {
+					i++
+				}
+			}
+			if i >= len(query) {
+				return "", nil, fmt.Errorf("unterminated %c at offset %d", c, start)
+			}
+		case c == '-' && i+1 < len(query) && query[i+1] == '-', c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			for i += 2; i+1 < len(query) && !(query[i] == '*' && query[i+1] == '/'); i++ {
+			}
+			i++
+		}
+	}
+	b.WriteString(query[last:])
+	return b.String(), names, nil
+}
+
+func namedArgs(names []string, arg interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("NamedExec(non-struct %T)", arg)
+	}
+	fields := structColumns(v.Type())
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		sf, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("parameter :%s not found in %T", name, arg)
+		}
+		field, ok := namedField(v, sf.index)
+		if !ok || field.Kind() == reflect.Ptr && field.IsNil() {
+			continue
+		}
+		if sf.json {
+			b, err := json.Marshal(field.Interface())
+			if err != nil {
+				return nil, fmt.Errorf("parameter :%s: %v", name, err)
+			}
+			args[i] = string(b)
+			continue
+		}
+		if field.Kind() == reflect.Ptr {
+			field = field.Elem()
+		}
+		args[i] = field.Interface()
+	}
+	return args, nil
+}
+
+// namedField is fieldByIndex without allocating: a nil embedded pointer on
+// the way yields false, binding NULL.
+func namedField(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
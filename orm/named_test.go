@@ -0,0 +1,19 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileNamed(t *testing.T) {
+	query, names, err := compileNamed("UPDATE t SET a = :a, b = ':x', c = @v := :c::int WHERE id = :id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "UPDATE t SET a = ?, b = ':x', c = @v := ?::int WHERE id = ?"; query != expect {
+		t.Errorf("expected %q, got %q", expect, query)
+	}
+	if strings.Join(names, ",") != "a,c,id" {
+		t.Errorf("expected a,c,id, got %v", names)
+	}
+}
@@ -0,0 +1,111 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// mysqlDatetime is the layout of DATETIME columns read without parseTime.
+const mysqlDatetime = "2006-01-02 15:04:05.999999999"
+
+// NullTime is a nullable time.Time. Besides time.Time values it scans text
+// in the mysql DATETIME format, RFC 3339 or a RegisterTimeLayouts layout.
+type NullTime struct {
+	Time  time.Time
+	Valid bool
+}
+
+func (n *NullTime) Scan(src interface{}) error {
+	n.Time, n.Valid = time.Time{}, src != nil
+	if src == nil {
+		return nil
+	}
+	conv, _ := timeConverter(timeType, []string{mysqlDatetime, time.RFC3339Nano})
+	return conv.ConvertScan(src, reflect.ValueOf(&n.Time).Elem())
+}
+
+func (n NullTime) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Time, nil
+}
+
+// NullJSON is a nullable JSON column holding a T, written as JSON text.
+type NullJSON[T any] struct {
+	Val   T
+	Valid bool
+}
+
+func (n *NullJSON[T]) Scan(src interface{}) error {
+	var zero T
+	n.Val, n.Valid = zero, src != nil
+	if src == nil {
+		return nil
+	}
+	return convertJSON(src, reflect.ValueOf(&n.Val).Elem())
+}
+
+func (n NullJSON[T]) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	b, err := json.Marshal(n.Val)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// NullDecimal is a nullable DECIMAL column held exactly as a big.Rat.
+type NullDecimal struct {
+	Rat   big.Rat
+	Valid bool
+}
+
+func (n *NullDecimal) Scan(src interface{}) error {
+	n.Rat.SetInt64(0)
+	n.Valid = src != nil
+	if src == nil {
+		return nil
+	}
+	return convertBigRat(src, reflect.ValueOf(&n.Rat).Elem())
+}
+
+// Value writes the decimal as exact text, failing for fractions such as
+// 1/3 that have no finite decimal form.
+func (n NullDecimal) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	digits, ok := decimalDigits(&n.Rat)
+	if !ok {
+		return nil, fmt.Errorf("%s has no finite decimal form", n.Rat.RatString())
+	}
+	return n.Rat.FloatString(digits), nil
+}
+
+// decimalDigits returns the number of fractional digits r needs, false if
+// its denominator has prime factors other than 2 and 5.
+func decimalDigits(r *big.Rat) (int, bool) {
+	d := new(big.Int).Set(r.Denom())
+	two, five, mod := big.NewInt(2), big.NewInt(5), new(big.Int)
+	twos, fives := 0, 0
+	for ; mod.Mod(d, two).Sign() == 0; twos++ {
+		d.Quo(d, two)
+	}
+	for ; mod.Mod(d, five).Sign() == 0; fives++ {
+		d.Quo(d, five)
+	}
+	if !d.IsInt64() || d.Int64() != 1 {
+		return 0, false
+	}
+	if twos > fives {
+		return twos, true
+	}
+	return fives, true
+}
@@ -0,0 +1,74 @@
+package orm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNullDecimalValue(t *testing.T) {
+	cases := []struct {
+		rat, out string
+	}{
+		{"41/4", "10.25"},
+		{"3", "3"},
+		{"-1/8", "-0.125"},
+		{"1/3", ""},
+	}
+	for i, c := range cases {
+		var n NullDecimal
+		n.Rat.SetString(c.rat)
+		n.Valid = true
+		v, err := n.Value()
+		if c.out == "" && err == nil || c.out != "" && v != c.out {
+			t.Errorf("#%d expected %q, got %v (%v)", i+1, c.out, v, err)
+		}
+	}
+}
+
+func TestNullTimeScan(t *testing.T) {
+	local := time.Date(2024, 3, 9, 14, 5, 6, 0, time.Local)
+	utc := time.Date(2024, 3, 9, 14, 5, 6, 0, time.UTC)
+	cases := []struct {
+		src   interface{}
+		valid bool
+		out   time.Time
+	}{
+		{nil, false, time.Time{}},
+		{utc, true, utc},
+		{[]byte("2024-03-09 14:05:06"), true, local},
+		{"2024-03-09 14:05:06", true, local},
+		{[]byte("2024-03-09T14:05:06Z"), true, utc},
+		{"2024-03-09T14:05:06Z", true, utc},
+	}
+	for i, c := range cases {
+		n := NullTime{Time: time.Now(), Valid: !c.valid}
+		if err := n.Scan(c.src); err != nil || n.Valid != c.valid || !n.Time.Equal(c.out) {
+			t.Errorf("#%d expected %v (valid %v), got %v (valid %v, %v)", i+1, c.out, c.valid, n.Time, n.Valid, err)
+		}
+	}
+	var n NullTime
+	if err := n.Scan("not a time"); err == nil {
+		t.Errorf("expected error, got %v", n.Time)
+	}
+}
+
+func TestNullJSON(t *testing.T) {
+	in := NullJSON[scanLine]{Val: scanLine{"s1", 2}, Valid: true}
+	v, err := in.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, src := range []interface{}{v, []byte(v.(string))} {
+		var out NullJSON[scanLine]
+		if err := out.Scan(src); err != nil || out != in {
+			t.Errorf("#%d expected %+v, got %+v (%v)", i+1, in, out, err)
+		}
+	}
+	out := NullJSON[scanLine]{Val: scanLine{"s1", 2}, Valid: true}
+	if err := out.Scan(nil); err != nil || out.Valid || out.Val != (scanLine{}) {
+		t.Errorf("expected NULL, got %+v (%v)", out, err)
+	}
+	if v, err := (NullJSON[scanLine]{}).Value(); v != nil || err != nil {
+		t.Errorf("expected nil value, got %v (%v)", v, err)
+	}
+}
@@ -0,0 +1,65 @@
+package orm
+
+import (
+	"context"
+	"sync"
+)
+
+// queryParallelism bounds the connections QueryParallel uses at once.
+const queryParallelism = 8
+
+type QuerySpec struct {
+	SQL  string
+	Args []interface{}
+}
+
+type QueryResult struct {
+	Rows []map[string]interface{}
+	Err  error
+}
+
+// QueryParallel runs independent queries concurrently on separate pooled
+// connections, at most 8 or the pool's open connection limit at a time.
+// Results are in the order of specs. The first failure cancels the queries
+// still running and is returned; every result carries its own error.
+func (store *DBStore) QueryParallel(ctx context.Context, specs []QuerySpec) ([]QueryResult, error) {
+	workers := queryParallelism
+	if max := store.DB.Stats().MaxOpenConnections; max > 0 && max < workers {
+		workers = max
+	}
+	if len(specs) < workers {
+		workers = len(specs)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]QueryResult, len(specs))
+	next := make(chan int)
+	var once sync.Once
+	var first error
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				rows, err := store.QueryContext(ctx, specs[i].SQL, specs[i].Args...)
+				if err == nil {
+					results[i].Rows, err = rowsToMaps(rows, store.rowLimit(ctx))
+				}
+				if results[i].Err = err; err != nil {
+					once.Do(func() {
+						first = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+	for i := range specs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results, first
+}
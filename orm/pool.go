@@ -0,0 +1,102 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Warmup opens n connections in parallel and pings each before handing
+// them back to the pool, so the first requests don't pay for connection
+// setup. Connections beyond SetMaxIdleConns are closed again on release.
+func (store *DBStore) Warmup(ctx context.Context, n int) error {
+	if n < 0 {
+		return fmt.Errorf("negative warmup count %d", n)
+	}
+	if n == 0 {
+		return nil
+	}
+	conns := make([]*sql.Conn, n)
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := store.DB.Conn(ctx)
+			if err == nil {
+				conns[i] = conn
+				err = conn.PingContext(ctx)
+			}
+			if err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for _, conn := range conns {
+		if conn != nil {
+			conn.Close()
+		}
+	}
+	return <-errs
+}
+
+// ErrPoolTimeout is returned when no pooled connection frees up within the
+// acquire timeout.
+var ErrPoolTimeout = errors.New("timed out waiting for a pooled connection")
+
+// SetAcquireTimeout bounds the wait for a pooled connection separately from
+// the statement: waiting longer than d fails with ErrPoolTimeout, telling
+// pool exhaustion apart from slow queries. Zero waits as long as the
+// statement's context allows.
+func (store *DBStore) SetAcquireTimeout(d time.Duration) {
+	store.acquireTimeout = d
+}
+
+func (store *DBStore) acquire(ctx context.Context) (*sql.Conn, error) {
+	if store.acquireTimeout <= 0 {
+		return store.DB.Conn(ctx)
+	}
+	actx, cancel := context.WithTimeout(ctx, store.acquireTimeout)
+	defer cancel()
+	conn, err := store.DB.Conn(actx)
+	if err != nil && actx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return nil, ErrPoolTimeout
+	}
+	return conn, err
+}
+
+// runner is what a statement runs on: the pool, or a connection acquired
+// ahead of it under the acquire timeout.
+type runner interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (store *DBStore) runner(ctx context.Context) (runner, *sql.Conn, error) {
+	if store.acquireTimeout <= 0 {
+		return store.DB, nil, nil
+	}
+	conn, err := store.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, conn, nil
+}
+
+// releaseAfterRows hands an acquired conn back to the pool once rows, if
+// any, are closed; Conn.Close waits for them.
+func releaseAfterRows(conn *sql.Conn, rows *sql.Rows) {
+	switch {
+	case conn == nil:
+	case rows == nil:
+		conn.Close()
+	default:
+		go conn.Close()
+	}
+}
@@ -0,0 +1,76 @@
+package orm
+
+import "fmt"
+
+// rowSavepoint isolates the rows of PreparedExecIsolated.
+const rowSavepoint = "orm_row"
+
+// PreparedExec prepares query once and executes it for every set of args,
+// closing the statement afterwards. The first failure stops the loop and
+// marks the transaction for rollback.
+func (tx *DBTx) PreparedExec(query string, argSets ...[]interface{}) error {
+	ctx := tx.context()
+	stmt, err := tx.tx.PrepareContext(ctx, query)
+	if err != nil {
+		tx.err = err
+		return err
+	}
+	defer stmt.Close()
+	for _, args := range argSets {
+		done := tx.statement(ctx, query, args)
+		_, err := stmt.ExecContext(ctx, args...)
+		done(err)
+		if err != nil {
+			tx.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// RowError is the failure of one set of args in PreparedExecIsolated.
+type RowError struct {
+	Index int
+	Err   error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Index, e.Err)
+}
+
+// PreparedExecIsolated is PreparedExec running each set of args inside its
+// own savepoint: a failing row is rolled back alone and collected, the
+// remaining rows still run and the transaction stays committable. Only a
+// savepoint failure aborts the loop with an error.
+func (tx *DBTx) PreparedExecIsolated(query string, argSets ...[]interface{}) ([]RowError, error) {
+	ctx := tx.context()
+	stmt, err := tx.tx.PrepareContext(ctx, query)
+	if err != nil {
+		tx.err = err
+		return nil, err
+	}
+	defer stmt.Close()
+	rowErrs := []RowError{}
+	for i, args := range argSets {
+		if err := tx.Savepoint(rowSavepoint); err != nil {
+			tx.err = err
+			return rowErrs, err
+		}
+		done := tx.statement(ctx, query, args)
+		_, err := stmt.ExecContext(ctx, args...)
+		done(err)
+		if err != nil {
+			rowErrs = append(rowErrs, RowError{Index: i, Err: err})
+			if err := tx.RollbackTo(rowSavepoint); err != nil {
+				tx.err = err
+				return rowErrs, err
+			}
+			continue
+		}
+		if err := tx.Release(rowSavepoint); err != nil {
+			tx.err = err
+			return rowErrs, err
+		}
+	}
+	return rowErrs, nil
+}
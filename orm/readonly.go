@@ -0,0 +1,35 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrReadOnlyMode is returned for writes while a store is in read-only mode.
+var ErrReadOnlyMode = errors.New("store is in read-only mode")
+
+// SetReadOnly switches read-only mode, e.g. for a primary maintenance
+// window: Exec and BeginTx fail with ErrReadOnlyMode without reaching the
+// database while queries and BeginReadOnlyTx proceed.
+func (store *DBStore) SetReadOnly(b bool) {
+	var v int32
+	if b {
+		v = 1
+	}
+	atomic.StoreInt32(&store.readOnly, v)
+}
+
+func (store *DBStore) checkWritable() error {
+	if atomic.LoadInt32(&store.readOnly) == 1 {
+		return ErrReadOnlyMode
+	}
+	return nil
+}
+
+// BeginReadOnlyTx begins a read-only transaction, which is allowed in
+// read-only mode.
+func (store *DBStore) BeginReadOnlyTx(ctx context.Context) (TX, error) {
+	return store.beginTx(ctx, &sql.TxOptions{ReadOnly: true})
+}
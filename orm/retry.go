@@ -0,0 +1,115 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+)
+
+var (
+	_jitterMu     sync.Mutex
+	_jitterSource rand.Source = rand.NewSource(time.Now().UnixNano())
+)
+
+// RetryConfig bounds how often a transient connection failure is retried.
+// The zero value disables retrying. Retryable decides which errors are
+// transient and defaults to IsRetryable. Each wait is drawn uniformly from
+// [0, backoff) so that instances don't retry in lockstep after a failover;
+// Source seeds that draw, e.g. for deterministic tests, and defaults to a
+// time seeded source.
+type RetryConfig struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Retryable   func(err error) bool
+	Source      rand.Source
+}
+
+func (store *DBStore) SetRetry(cfg RetryConfig) {
+	store.retry = cfg
+}
+
+// SetRetryPredicate overrides the transient error classification. Wrap
+// IsRetryable to extend the defaults rather than replace them.
+func (store *DBStore) SetRetryPredicate(fn func(err error) bool) {
+	store.retry.Retryable = fn
+}
+
+// IsRetryable is the default retry predicate, matching broken connections.
+func IsRetryable(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn)
+}
+
+func (cfg RetryConfig) backoff(attempt int) time.Duration {
+	d := cfg.Backoff << uint(attempt)
+	if cfg.MaxBackoff > 0 && (d > cfg.MaxBackoff || d <= 0) {
+		d = cfg.MaxBackoff
+	}
+	return d
+}
+
+// jitter draws the wait before a retry from [0, d).
+func (cfg RetryConfig) jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	src := cfg.Source
+	if src == nil {
+		src = _jitterSource
+	}
+	_jitterMu.Lock()
+	defer _jitterMu.Unlock()
+	return time.Duration(rand.New(src).Int63n(int64(d)))
+}
+
+func (cfg RetryConfig) retryable(err error) bool {
+	if cfg.Retryable != nil {
+		return cfg.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+func (cfg RetryConfig) do(ctx context.Context, fn func() error) error {
+	err := fn()
+	for attempt := 1; attempt < cfg.MaxAttempts && err != nil && cfg.retryable(err); attempt++ {
+		if sleepContext(ctx, cfg.jitter(cfg.backoff(attempt-1))) != nil {
+			return err
+		}
+		logRetry(ctx, attempt, err)
+		err = fn()
+	}
+	return err
+}
+
+// logRetry records a retry as a db.retry event on the span of ctx, if any,
+// so traces show statements that only succeeded after retrying.
+func logRetry(ctx context.Context, attempt int, err error) {
+	if ctx == nil {
+		return
+	}
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span.LogFields(otlog.String("event", "db.retry"), otlog.Int("attempt", attempt), otlog.Error(err))
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if ctx == nil {
+		time.Sleep(d)
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
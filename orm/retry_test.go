@@ -0,0 +1,22 @@
+package orm
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestRetryJitter(t *testing.T) {
+	a := RetryConfig{Backoff: 100 * time.Millisecond, Source: rand.NewSource(1)}
+	b := RetryConfig{Backoff: 100 * time.Millisecond, Source: rand.NewSource(1)}
+	for attempt := 0; attempt < 5; attempt++ {
+		d := a.backoff(attempt)
+		x, y := a.jitter(d), b.jitter(d)
+		if x != y {
+			t.Errorf("#%d expected equal waits for equal seeds, got %s and %s", attempt, x, y)
+		}
+		if x < 0 || x >= d {
+			t.Errorf("#%d expected wait in [0, %s), got %s", attempt, d, x)
+		}
+	}
+}
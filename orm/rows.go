@@ -0,0 +1,182 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// EachRaw calls fn for every row with the raw column bytes, pointing into
+// the driver's buffer instead of copying each value. This keeps large BLOB
+// columns from being allocated once per row. The slices are reused between
+// rows and are only valid until fn returns; copy whatever must outlive it.
+// rows is always closed.
+func EachRaw(rows *sql.Rows, fn func(cols []sql.RawBytes) error) error {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	raw := make([]sql.RawBytes, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err := fn(raw); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// RowsToMaps reads all rows into maps keyed by column name. rows is always
+// closed.
+func RowsToMaps(rows *sql.Rows) ([]map[string]interface{}, error) {
+	return rowsToMaps(rows, &rowCounter{})
+}
+
+// RowsToMapsMax is RowsToMaps failing with ErrTooManyRows after max rows,
+// unless max is 0.
+func RowsToMapsMax(rows *sql.Rows, max int64) ([]map[string]interface{}, error) {
+	return rowsToMaps(rows, &rowCounter{max: max})
+}
+
+func rowsToMaps(rows *sql.Rows, limit *rowCounter) ([]map[string]interface{}, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		if err := limit.add(); err != nil {
+			return nil, err
+		}
+		row, err := scanRowMap(rows, columns)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func scanRowMap(rows *sql.Rows, columns []string) (map[string]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(columns))
+	for i, col := range columns {
+		row[col] = values[i]
+	}
+	return row, nil
+}
+
+// ForEachBatch streams the result of sql and calls fn with up to batchSize
+// rows at a time, the last batch holding the remainder.
+func (store *DBStore) ForEachBatch(ctx context.Context, batchSize int, fn func(batch []map[string]interface{}) error, sql string, args ...interface{}) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("invalid batch size: %d", batchSize)
+	}
+	rows, err := store.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	batch := make([]map[string]interface{}, 0, batchSize)
+	limit := store.rowLimit(ctx)
+	for rows.Next() {
+		if err := limit.add(); err != nil {
+			return err
+		}
+		row, err := scanRowMap(rows, columns)
+		if err != nil {
+			return err
+		}
+		if batch = append(batch, row); len(batch) == batchSize {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = make([]map[string]interface{}, 0, batchSize)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+// ScanSelected scans the current row and returns the values of cols only,
+// keyed by column name. It fails if a column is missing from the result.
+func ScanSelected(rows *sql.Rows, cols []string) (map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	present := NewStringSet(columns...)
+	for _, col := range cols {
+		if !present.Contains(col) {
+			return nil, fmt.Errorf("column %s not in result %v", col, columns)
+		}
+	}
+	row, err := scanRowMap(rows, columns)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]interface{}, len(cols))
+	for _, col := range cols {
+		selected[col] = row[col]
+	}
+	return selected, nil
+}
+
+// QueryEach runs sql and calls fn for every row like EachRaw, reporting the
+// number of rows read to the store's RowsMetrics.
+func (store *DBStore) QueryEach(ctx context.Context, fn func(cols []sql.RawBytes) error, query string, args ...interface{}) error {
+	rows, err := store.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	limit := store.rowLimit(ctx)
+	err = EachRaw(rows, func(cols []sql.RawBytes) error {
+		if err := limit.add(); err != nil {
+			return err
+		}
+		return fn(cols)
+	})
+	if m, ok := store.metrics.(RowsMetrics); ok {
+		m.ObserveRows(Fingerprint(query), limit.n)
+	}
+	return err
+}
+
+// QueryCancelable is Query returning a cancel func that aborts the query,
+// including while its rows are read, e.g. from an admin endpoint. The
+// driver stops waiting for the server; on mysql pair it with KILL QUERY to
+// stop the server side work. Call cancel once done with rows to release the
+// context.
+func (store *DBStore) QueryCancelable(sql string, args ...interface{}) (*sql.Rows, context.CancelFunc, error) {
+	ctx, cancel := context.WithCancel(store.context())
+	rows, err := store.QueryContext(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return rows, cancel, nil
+}
@@ -0,0 +1,52 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRowsClosedOnError(t *testing.T) {
+	store := fakeStore("SELECT broken", []string{"id", "name"},
+		[]driver.Value{int64(1), "a"},
+		[]driver.Value{nil, "b"},
+	)
+	ctx := context.Background()
+	failed := errors.New("failed")
+	cases := []func() error{
+		func() error {
+			rows, err := store.Query("SELECT broken")
+			if err != nil {
+				return err
+			}
+			var orders []scanOrder
+			return ScanStructs(rows, &orders)
+		},
+		func() error {
+			return store.QueryEach(ctx, func([]sql.RawBytes) error { return failed }, "SELECT broken")
+		},
+		func() error {
+			return store.ForEachBatch(ctx, 1, func([]map[string]interface{}) error { return failed }, "SELECT broken")
+		},
+		func() error {
+			rows, errc := Stream[scanOrder](ctx, store, "SELECT broken")
+			for range rows {
+			}
+			return <-errc
+		},
+		func() error {
+			return store.QueryJSON(WithMaxRows(ctx, 1), ioutil.Discard, "SELECT broken")
+		},
+	}
+	for i, fn := range cases {
+		if err := fn(); err == nil {
+			t.Errorf("#%d expected error", i+1)
+		}
+		if n := store.Stats().InUse; n != 0 {
+			t.Errorf("#%d expected idle pool, got %d connections in use", i+1, n)
+		}
+	}
+}
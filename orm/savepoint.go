@@ -0,0 +1,70 @@
+package orm
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var savepointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Savepoint marks a point inside the transaction that RollbackTo can return
+// to without aborting the transaction.
+func (tx *DBTx) Savepoint(name string) error {
+	if tx.driver == "mssql" {
+		return tx.savepointExec("SAVE TRANSACTION %s", name)
+	}
+	return tx.savepointExec("SAVEPOINT %s", name)
+}
+
+// RollbackTo undoes the statements run since Savepoint(name).
+func (tx *DBTx) RollbackTo(name string) error {
+	if tx.driver == "mssql" {
+		return tx.savepointExec("ROLLBACK TRANSACTION %s", name)
+	}
+	return tx.savepointExec("ROLLBACK TO SAVEPOINT %s", name)
+}
+
+// Release forgets a savepoint. mssql has no release, savepoints end with
+// the transaction.
+func (tx *DBTx) Release(name string) error {
+	if tx.driver == "mssql" {
+		if !savepointName.MatchString(name) {
+			return fmt.Errorf("invalid savepoint name: %q", name)
+		}
+		return nil
+	}
+	return tx.savepointExec("RELEASE SAVEPOINT %s", name)
+}
+
+func (tx *DBTx) savepointExec(format, name string) error {
+	if tx.tx == nil {
+		return ErrNotInTransaction
+	}
+	if !savepointName.MatchString(name) {
+		return fmt.Errorf("invalid savepoint name: %q", name)
+	}
+	query := fmt.Sprintf(format, name)
+	done := tx.statement(tx.context(), query, nil)
+	_, err := tx.tx.ExecContext(tx.context(), query)
+	done(err)
+	return err
+}
+
+// WithNested runs fn inside savepoint name. The savepoint is released if fn
+// succeeds and rolled back to if it fails, in which case fn's error is
+// returned and the outer transaction stays committable.
+func (tx *DBTx) WithNested(name string, fn func(TX) error) error {
+	if err := tx.Savepoint(name); err != nil {
+		return err
+	}
+	prev := tx.err
+	if err := fn(tx); err != nil {
+		if rerr := tx.RollbackTo(name); rerr != nil {
+			tx.err = rerr
+			return fmt.Errorf("%v (rollback to savepoint %s: %v)", err, name, rerr)
+		}
+		tx.err = prev
+		return err
+	}
+	return tx.Release(name)
+}
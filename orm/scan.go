@@ -0,0 +1,328 @@
+package orm
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ScanConverter converts a non-NULL column value into dest, an addressable
+// value of the type the converter was registered for. It serves field types
+// that database/sql can not scan into, such as decimals.
+type ScanConverter interface {
+	ConvertScan(src interface{}, dest reflect.Value) error
+}
+
+type ScanConverterFunc func(src interface{}, dest reflect.Value) error
+
+func (f ScanConverterFunc) ConvertScan(src interface{}, dest reflect.Value) error {
+	return f(src, dest)
+}
+
+var (
+	_scanConvertersMu sync.RWMutex
+	_scanConverters   = map[reflect.Type]ScanConverter{}
+
+	_structColumnsMu sync.RWMutex
+	_structColumns   = map[reflect.Type]map[string]structField{}
+
+	jsonConverter = ScanConverterFunc(convertJSON)
+)
+
+// structField is the field a column maps to. json is set by the json tag
+// option, db:"items,json", for columns holding JSON to unmarshal.
+type structField struct {
+	index   []int
+	json    bool
+	layouts []string
+}
+
+func init() {
+	RegisterScanConverter(reflect.TypeOf(big.Rat{}), ScanConverterFunc(convertBigRat))
+	RegisterScanConverter(reflect.TypeOf(big.Int{}), ScanConverterFunc(convertBigInt))
+}
+
+// RegisterScanConverter makes the struct scan helpers use c for fields of
+// type typ or *typ.
+func RegisterScanConverter(typ reflect.Type, c ScanConverter) {
+	_scanConvertersMu.Lock()
+	defer _scanConvertersMu.Unlock()
+	_scanConverters[typ] = c
+}
+
+func scanConverterFor(typ reflect.Type) (ScanConverter, bool) {
+	_scanConvertersMu.RLock()
+	defer _scanConvertersMu.RUnlock()
+	if c, ok := _scanConverters[typ]; ok {
+		return c, true
+	}
+	if typ.Kind() == reflect.Ptr {
+		c, ok := _scanConverters[typ.Elem()]
+		return c, ok
+	}
+	return nil, false
+}
+
+func numericString(src interface{}) string {
+	switch v := src.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	}
+	return fmt.Sprint(src)
+}
+
+func convertBigRat(src interface{}, dest reflect.Value) error {
+	r := dest.Addr().Interface().(*big.Rat)
+	if f, ok := src.(float64); ok {
+		if r.SetFloat64(f) == nil {
+			return fmt.Errorf("can't convert %v to big.Rat", f)
+		}
+		return nil
+	}
+	if _, ok := r.SetString(numericString(src)); !ok {
+		return fmt.Errorf("can't convert %q to big.Rat", numericString(src))
+	}
+	return nil
+}
+
+// convertJSON unmarshals a JSON column, e.g. from JSON_ARRAYAGG, into a
+// slice, map or nested struct field.
+func convertJSON(src interface{}, dest reflect.Value) error {
+	var b []byte
+	switch v := src.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("can't unmarshal %T as JSON", src)
+	}
+	return json.Unmarshal(b, dest.Addr().Interface())
+}
+
+func convertBigInt(src interface{}, dest reflect.Value) error {
+	i := dest.Addr().Interface().(*big.Int)
+	if _, ok := i.SetString(numericString(src), 10); !ok {
+		return fmt.Errorf("can't convert %q to big.Int", numericString(src))
+	}
+	return nil
+}
+
+// structColumns maps the column names of a struct type, taken from the db
+// tag or the lower-cased field name, to their field. Fields tagged db:"-"
+// are skipped and embedded structs are flattened.
+func structColumns(typ reflect.Type) map[string]structField {
+	_structColumnsMu.RLock()
+	columns, ok := _structColumns[typ]
+	_structColumnsMu.RUnlock()
+	if ok {
+		return columns
+	}
+	columns = map[string]structField{}
+	collectStructColumns(typ, nil, columns)
+	_structColumnsMu.Lock()
+	_structColumns[typ] = columns
+	_structColumnsMu.Unlock()
+	return columns
+}
+
+func collectStructColumns(typ reflect.Type, prefix []int, columns map[string]structField) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		index := append(append([]int{}, prefix...), i)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			collectStructColumns(ft, index, columns)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		if _, ok := columns[name]; !ok || len(index) < len(columns[name].index) {
+			columns[name] = structField{
+				index:   index,
+				json:    NewStringSet(opts[1:]...).Contains("json"),
+				layouts: fieldTimeLayouts(field.Tag),
+			}
+		}
+	}
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex allocating nil embedded
+// struct pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+type convertedField struct {
+	field reflect.Value
+	conv  ScanConverter
+	src   *interface{}
+	err   func(err error) error
+}
+
+func (c convertedField) apply() error {
+	if *c.src == nil {
+		c.field.Set(reflect.Zero(c.field.Type()))
+		return nil
+	}
+	dest := c.field
+	if dest.Kind() == reflect.Ptr {
+		dest.Set(reflect.New(dest.Type().Elem()))
+		dest = dest.Elem()
+	}
+	if err := c.conv.ConvertScan(*c.src, dest); err != nil {
+		return c.err(err)
+	}
+	return nil
+}
+
+// ScanStruct scans the current row into the struct dest points to,
+// matching columns to fields by their db tag. Columns without a field are
+// discarded.
+func ScanStruct(rows *sql.Rows, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ScanStruct(non-struct-pointer %T)", dest)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	return scanStruct(rows, columns, v.Elem())
+}
+
+func scanStruct(rows *sql.Rows, columns []string, v reflect.Value) error {
+	fields := structColumns(v.Type())
+	targets := make([]interface{}, len(columns))
+	converted := []convertedField{}
+	scanErrs := make([]func(err error) error, len(columns))
+	for i, col := range columns {
+		sf, ok := fields[col]
+		if !ok {
+			targets[i] = new(interface{})
+			continue
+		}
+		field := fieldByIndex(v, sf.index)
+		scanErrs[i] = scanError(col, v.Type().FieldByIndex(sf.index))
+		conv, ok := scanConverterFor(field.Type())
+		if sf.json {
+			conv, ok = jsonConverter, true
+		} else if !ok {
+			conv, ok = timeConverter(field.Type(), sf.layouts)
+		}
+		if ok {
+			src := new(interface{})
+			targets[i] = src
+			converted = append(converted, convertedField{field: field, conv: conv, src: src, err: scanErrs[i]})
+			continue
+		}
+		targets[i] = field.Addr().Interface()
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return findScanError(rows, targets, scanErrs, err)
+	}
+	for _, c := range converted {
+		if err := c.apply(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanError(col string, field reflect.StructField) func(err error) error {
+	return func(err error) error {
+		return &ScanError{Column: col, Field: field.Name, Type: field.Type.String(), Err: err}
+	}
+}
+
+// findScanError rescans the row one field at a time to tell which column
+// failed, the error of database/sql naming the column but not the field.
+func findScanError(rows *sql.Rows, targets []interface{}, scanErrs []func(err error) error, err error) error {
+	probe := make([]interface{}, len(targets))
+	for i := range targets {
+		if scanErrs[i] == nil {
+			continue
+		}
+		for j := range probe {
+			probe[j] = new(interface{})
+		}
+		probe[i] = targets[i]
+		if perr := rows.Scan(probe...); perr != nil {
+			return scanErrs[i](perr)
+		}
+	}
+	return err
+}
+
+// ScanStructs appends every row to the slice dest points to, which may hold
+// structs or struct pointers. rows is always closed.
+func ScanStructs(rows *sql.Rows, dest interface{}) error {
+	return ScanStructsMax(rows, dest, 0)
+}
+
+// ScanStructsMax is ScanStructs failing with ErrTooManyRows after max rows,
+// unless max is 0.
+func ScanStructsMax(rows *sql.Rows, dest interface{}, max int64) error {
+	defer rows.Close()
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("ScanStructs(non-slice-pointer %T)", dest)
+	}
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	ptr := elem.Kind() == reflect.Ptr
+	if ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("ScanStructs(non-struct-slice %T)", dest)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	limit := &rowCounter{max: max}
+	for rows.Next() {
+		if err := limit.add(); err != nil {
+			return err
+		}
+		item := reflect.New(elem)
+		if err := scanStruct(rows, columns, item.Elem()); err != nil {
+			return err
+		}
+		if ptr {
+			slice.Set(reflect.Append(slice, item))
+		} else {
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,114 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"math/big"
+	"testing"
+)
+
+type scanBase struct {
+	Id int64 `db:"id"`
+}
+
+type scanOrder struct {
+	scanBase
+	Name     string     `db:"name"`
+	Price    big.Rat    `db:"price"`
+	Discount *big.Rat   `db:"discount"`
+	Ignored  string     `db:"-"`
+	Lines    []scanLine `db:"lines,json"`
+}
+
+type scanLine struct {
+	Sku string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+func TestScanStructs(t *testing.T) {
+	store := fakeStore("SELECT orders", []string{"id", "name", "price", "discount", "extra", "lines"},
+		[]driver.Value{int64(1), "a", []byte("10.25"), nil, "x", []byte(`[{"sku":"s1","qty":2}]`)},
+		[]driver.Value{int64(2), "b", []byte("3"), []byte("0.5"), "y", nil},
+	)
+	rows, err := store.Query("SELECT orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orders []*scanOrder
+	if err := ScanStructs(rows, &orders); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(orders))
+	}
+	if o := orders[0]; o.Id != 1 || o.Name != "a" || o.Price.RatString() != "41/4" || o.Discount != nil ||
+		len(o.Lines) != 1 || o.Lines[0] != (scanLine{"s1", 2}) {
+		t.Errorf("unexpected first row %+v", o)
+	}
+	if o := orders[1]; o.Id != 2 || o.Price.RatString() != "3" || o.Discount.RatString() != "1/2" || o.Lines != nil {
+		t.Errorf("unexpected second row %+v", o)
+	}
+}
+
+func TestScanStructError(t *testing.T) {
+	store := fakeStore("SELECT orders", []string{"id", "name"},
+		[]driver.Value{nil, "a"},
+	)
+	rows, err := store.Query("SELECT orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orders []scanOrder
+	err = ScanStructs(rows, &orders)
+	se, ok := err.(*ScanError)
+	if !ok || se.Column != "id" || se.Field != "Id" || se.Type != "int64" {
+		t.Errorf("expected scan error on id, got %v", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	store := fakeStore("SELECT orders", []string{"id", "name"},
+		[]driver.Value{int64(1), "a"},
+		[]driver.Value{int64(2), "b"},
+	)
+	rows, errc := Stream[*scanOrder](context.Background(), store, "SELECT orders")
+	var names string
+	for o := range rows {
+		names += o.Name
+	}
+	if err := <-errc; err != nil || names != "ab" {
+		t.Errorf("expected ab, got %q (%v)", names, err)
+	}
+}
+
+func TestStreamScanner(t *testing.T) {
+	store := fakeStore("SELECT names", []string{"name"},
+		[]driver.Value{"a"},
+		[]driver.Value{nil},
+	)
+	rows, errc := Stream[sql.NullString](context.Background(), store, "SELECT names")
+	var names []sql.NullString
+	for n := range rows {
+		names = append(names, n)
+	}
+	if err := <-errc; err != nil || len(names) != 2 ||
+		names[0] != (sql.NullString{String: "a", Valid: true}) || names[1].Valid {
+		t.Errorf("expected [a null], got %v (%v)", names, err)
+	}
+}
+
+func TestQueryColumn(t *testing.T) {
+	store := fakeStore("SELECT ids", []string{"id", "name"},
+		[]driver.Value{int64(1), "a"},
+		[]driver.Value{int64(2), "b"},
+	)
+	ids, err := QueryColumn[int64](context.Background(), store, "SELECT ids")
+	if err != nil || len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("expected [1 2], got %v (%v)", ids, err)
+	}
+	names, err := QueryColumn[string](context.Background(), store, "SELECT none")
+	if err != nil || names == nil || len(names) != 0 {
+		t.Errorf("expected empty slice, got %#v (%v)", names, err)
+	}
+}
@@ -0,0 +1,57 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TableExists reports whether table, optionally qualified by its schema as
+// schema.table, exists in the current database.
+func (store *DBStore) TableExists(ctx context.Context, table string) (bool, error) {
+	schema, name := splitTable(table)
+	switch store.driver {
+	case "mysql":
+		return store.exists(ctx, "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = COALESCE(?, DATABASE()) AND table_name = ?",
+			schema, name)
+	case "mssql":
+		return store.exists(ctx, "SELECT CASE WHEN OBJECT_ID(?, 'U') IS NULL THEN 0 ELSE 1 END", table)
+	}
+	return false, fmt.Errorf("unsupport db driver: %s", store.driver)
+}
+
+// ColumnExists reports whether table, as given to TableExists, has column.
+func (store *DBStore) ColumnExists(ctx context.Context, table, column string) (bool, error) {
+	schema, name := splitTable(table)
+	switch store.driver {
+	case "mysql":
+		return store.exists(ctx, "SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = COALESCE(?, DATABASE()) AND table_name = ? AND column_name = ?",
+			schema, name, column)
+	case "mssql":
+		return store.exists(ctx, "SELECT CASE WHEN COL_LENGTH(?, ?) IS NULL THEN 0 ELSE 1 END", table, column)
+	}
+	return false, fmt.Errorf("unsupport db driver: %s", store.driver)
+}
+
+// splitTable splits schema.table; schema is nil, i.e. NULL, if unqualified.
+func splitTable(table string) (schema interface{}, name string) {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		return table[:i], table[i+1:]
+	}
+	return nil, table
+}
+
+func (store *DBStore) exists(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	rows, err := store.QueryContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	var n int64
+	if rows.Next() {
+		if err := rows.Scan(&n); err != nil {
+			return false, err
+		}
+	}
+	return n > 0, rows.Err()
+}
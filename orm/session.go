@@ -0,0 +1,60 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+var sqlModeValue = regexp.MustCompile(`^[A-Za-z_,]*$`)
+
+// SetSQLMode sets the mysql session sql_mode, e.g. STRICT_TRANS_TABLES, on
+// every new connection through OnConnect, so that all environments write
+// with the same mode. Call it during setup.
+func (store *DBStore) SetSQLMode(mode string) error {
+	if store.driver != "mysql" {
+		return fmt.Errorf("unsupport db driver: %s", store.driver)
+	}
+	if !sqlModeValue.MatchString(mode) {
+		return fmt.Errorf("invalid sql_mode: %q", mode)
+	}
+	store.OnConnect(func(ctx context.Context, exec SessionExec) error {
+		return exec(ctx, "SET SESSION sql_mode = '"+mode+"'")
+	})
+	return nil
+}
+
+// GetSQLMode reads the sql_mode in effect on a pooled connection.
+func (store *DBStore) GetSQLMode(ctx context.Context) (string, error) {
+	var mode string
+	err := store.DB.QueryRowContext(ctx, "SELECT @@SESSION.sql_mode").Scan(&mode)
+	return mode, err
+}
+
+// SetAutocommit sets autocommit on every new connection: SET autocommit for
+// mysql and SET IMPLICIT_TRANSACTIONS for mssql. With autocommit off a
+// statement outside BeginTx opens an implicit transaction on whichever
+// pooled connection ran it, which nothing commits; use it only with
+// BeginTx, whose Close commits explicitly, or a pinned ConnDB issuing its
+// own COMMIT. Call it during setup.
+func (store *DBStore) SetAutocommit(b bool) error {
+	var stmt string
+	switch store.driver {
+	case "mysql":
+		stmt = "SET autocommit = 0"
+		if b {
+			stmt = "SET autocommit = 1"
+		}
+	case "mssql":
+		stmt = "SET IMPLICIT_TRANSACTIONS ON"
+		if b {
+			stmt = "SET IMPLICIT_TRANSACTIONS OFF"
+		}
+	default:
+		return fmt.Errorf("unsupport db driver: %s", store.driver)
+	}
+	store.OnConnect(func(ctx context.Context, exec SessionExec) error {
+		return exec(ctx, stmt)
+	})
+	return nil
+}
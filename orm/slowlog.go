@@ -0,0 +1,157 @@
+package orm
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const slowQueryChanSize = 1024
+
+var _pkgPath = reflect.TypeOf(options{}).PkgPath()
+
+type SlowQueryEvent struct {
+	SQL      string
+	Args     []interface{}
+	Duration time.Duration
+	// Caller is the file:line of the first caller outside this package.
+	Caller string
+}
+
+type slowQueryEvents struct {
+	ch      chan SlowQueryEvent
+	dropped uint64
+}
+
+// SlowQueryChan returns a buffered channel receiving an event for every
+// statement slower than SlowLog, in addition to the log line. Events are
+// dropped rather than blocking the statement when the channel is full, see
+// SlowQueriesDropped. Call it during setup, before the store is shared.
+func (store *DBStore) SlowQueryChan() <-chan SlowQueryEvent {
+	if store.slowEvents == nil {
+		store.slowEvents = &slowQueryEvents{ch: make(chan SlowQueryEvent, slowQueryChanSize)}
+	}
+	return store.slowEvents.ch
+}
+
+func (store *DBStore) SlowQueriesDropped() uint64 {
+	if store.slowEvents == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&store.slowEvents.dropped)
+}
+
+func (e *slowQueryEvents) publish(sql string, args []interface{}, d time.Duration) {
+	event := SlowQueryEvent{SQL: sql, Args: args, Duration: d, Caller: externalCaller()}
+	select {
+	case e.ch <- event:
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+	}
+}
+
+func externalCaller() string {
+	pc := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, _pkgPath+".") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// SlowQueryError is returned along with the result of a statement slower
+// than SlowLog while SetSlowQueryAsError is on.
+type SlowQueryError struct {
+	SQL       string
+	Duration  time.Duration
+	Threshold time.Duration
+}
+
+func (e *SlowQueryError) Error() string {
+	return fmt.Sprintf("slow query %s exceeds %s: %s", e.Duration, e.Threshold, e.SQL)
+}
+
+// SetSlowQueryAsError turns statements slower than SlowLog into failures,
+// e.g. to catch N+1 queries in CI. Exec returns its result along with a
+// *SlowQueryError; Query closes its rows and returns the error only, as
+// callers do not close rows on error. Inside a transaction the error also
+// makes Close roll back.
+func (store *DBStore) SetSlowQueryAsError(b bool) {
+	store.slowAsError = b
+}
+
+// slowLogSampler limits the slow log to one line per fingerprint and
+// window, so an incident doesn't flood the log with identical lines.
+type slowLogSampler struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples map[string]*slowLogSample
+}
+
+type slowLogSample struct {
+	logged     time.Time
+	suppressed int
+}
+
+// slowLogSamplerMax bounds the tracked fingerprints; the samples start over
+// when it is reached.
+const slowLogSamplerMax = 4096
+
+// SetSlowLogSampling logs a slow statement at most once per window and
+// fingerprint, the next line reporting how many were suppressed meanwhile.
+// Slow query events and metrics are not sampled. Zero logs every one.
+func (store *DBStore) SetSlowLogSampling(window time.Duration) {
+	if window <= 0 {
+		store.slowSampler = nil
+		return
+	}
+	store.slowSampler = &slowLogSampler{window: window, samples: map[string]*slowLogSample{}}
+}
+
+// sample reports whether to log sql now and how many lines were suppressed
+// since the last one.
+func (s *slowLogSampler) sample(sql string) (bool, int) {
+	fp := Fingerprint(sql)
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sample, ok := s.samples[fp]
+	if !ok {
+		if len(s.samples) >= slowLogSamplerMax {
+			s.samples = map[string]*slowLogSample{}
+		}
+		s.samples[fp] = &slowLogSample{logged: now}
+		return true, 0
+	}
+	if now.Sub(sample.logged) < s.window {
+		sample.suppressed++
+		return false, 0
+	}
+	suppressed := sample.suppressed
+	sample.logged, sample.suppressed = now, 0
+	return true, suppressed
+}
+
+func (o *options) logSlow(span time.Duration, sql string, args []interface{}) {
+	if o.slowSampler == nil {
+		log.Println("SLOW: ", span.String(), sql, args)
+		return
+	}
+	if ok, suppressed := o.slowSampler.sample(sql); ok {
+		if suppressed > 0 {
+			log.Println("SLOW: ", span.String(), sql, args, "suppressed", suppressed)
+			return
+		}
+		log.Println("SLOW: ", span.String(), sql, args)
+	}
+}
@@ -0,0 +1,46 @@
+package orm
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	_softDeleteMu     sync.RWMutex
+	_softDeleteTables = map[string]string{}
+)
+
+// RegisterSoftDelete marks table as soft-deletable: rows whose column is
+// not NULL are treated as deleted by SQLWhereSoftDelete.
+func RegisterSoftDelete(table, column string) {
+	_softDeleteMu.Lock()
+	defer _softDeleteMu.Unlock()
+	_softDeleteTables[table] = column
+}
+
+func UnregisterSoftDelete(table string) {
+	_softDeleteMu.Lock()
+	defer _softDeleteMu.Unlock()
+	delete(_softDeleteTables, table)
+}
+
+// SoftDeleteConditions appends the "column IS NULL" filter of table to
+// conditions if the table is registered, otherwise conditions are returned
+// unchanged.
+func SoftDeleteConditions(table string, conditions []string) []string {
+	_softDeleteMu.RLock()
+	column, ok := _softDeleteTables[table]
+	_softDeleteMu.RUnlock()
+	if !ok {
+		return conditions
+	}
+	out := make([]string, 0, len(conditions)+1)
+	out = append(out, conditions...)
+	return append(out, fmt.Sprintf("%s IS NULL", column))
+}
+
+// SQLWhereSoftDelete is SQLWhere for SELECTs on a possibly soft-deletable
+// table, hiding deleted rows.
+func SQLWhereSoftDelete(table string, conditions []string) string {
+	return SQLWhere(SoftDeleteConditions(table, conditions))
+}
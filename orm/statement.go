@@ -0,0 +1,295 @@
+package orm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	fingerprintIN     = regexp.MustCompile(`(?i)\bIN \( ?\?(?: ?, ?\?)* ?\)`)
+	fingerprintValues = regexp.MustCompile(`(?i)\bVALUES ?(\([^()]*\))(?: ?, ?\([^()]*\))+`)
+)
+
+// statementShape returns the upper-cased verb of query and the first table
+// it references, e.g. ("SELECT", "users"). Both are empty if the statement
+// can not be recognised.
+func statementShape(query string) (verb, table string) {
+	words := statementWords(query, 16)
+	if len(words) == 0 {
+		return "", ""
+	}
+	verb = strings.ToUpper(words[0])
+	var marker string
+	switch verb {
+	case "SELECT", "DELETE":
+		marker = "FROM"
+	case "INSERT", "REPLACE":
+		marker = "INTO"
+	case "UPDATE":
+		if len(words) > 1 {
+			table = words[1]
+		}
+	default:
+		if !isIdentWord(verb) {
+			return "", ""
+		}
+		return verb, ""
+	}
+	for i := 1; marker != "" && i < len(words)-1; i++ {
+		if strings.EqualFold(words[i], marker) {
+			table = words[i+1]
+			break
+		}
+	}
+	return verb, unquoteIdent(table)
+}
+
+// statementWords splits the leading part of query into at most n words,
+// skipping comments and string literals.
+func statementWords(query string, n int) []string {
+	words := []string{}
+	word := []rune{}
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(query)
+	for i := 0; i < len(runes) && len(words) < n; i++ {
+		c := runes[i]
+		switch {
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-', c == '#':
+			flush()
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			flush()
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+			}
+			i++
+		case c == '\'':
+			flush()
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+		case unicode.IsSpace(c) || c == '(' || c == ')' || c == ',' || c == ';':
+			flush()
+		default:
+			word = append(word, c)
+		}
+	}
+	flush()
+	return words
+}
+
+func isIdentWord(s string) bool {
+	for _, c := range s {
+		if !(c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func unquoteIdent(s string) string {
+	s = strings.NewReplacer("`", "", "[", "", "]", "", `"`, "").Replace(s)
+	if !isIdentWord(strings.Replace(s, ".", "", -1)) {
+		return ""
+	}
+	return s
+}
+
+// spanName names a trace span after the statement shape, falling back to
+// generic when the statement can not be recognised.
+func spanName(generic, query string) string {
+	verb, table := statementShape(query)
+	switch {
+	case verb == "":
+		return generic
+	case table == "":
+		return verb
+	}
+	return verb + " " + table
+}
+
+// Fingerprint normalizes a statement into a stable, low-cardinality label:
+// comments are dropped, whitespace is collapsed, literals become ? and
+// IN lists and multi-row VALUES collapse into a single group. Results are
+// cached, see SetFingerprintCacheSize.
+func Fingerprint(query string) string {
+	query = stripTraceComment(query)
+	if fp, ok := _fingerprints.get(query); ok {
+		return fp
+	}
+	fp := fingerprint(query)
+	_fingerprints.add(query, fp)
+	return fp
+}
+
+// stripTraceComment drops a leading comment such as the one TracedDB adds,
+// which is different for every statement, so that the fingerprint cache is
+// keyed by the statement itself.
+func stripTraceComment(query string) string {
+	if !strings.HasPrefix(query, "/*") {
+		return query
+	}
+	if i := strings.Index(query, "*/"); i >= 0 {
+		return strings.TrimLeft(query[i+2:], " ")
+	}
+	return query
+}
+
+func fingerprint(query string) string {
+	out := make([]rune, 0, len(query))
+	space := func() {
+		if len(out) > 0 && out[len(out)-1] != ' ' {
+			out = append(out, ' ')
+		}
+	}
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-', c == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			space()
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+			}
+			i++
+			space()
+		case c == '\'' || c == '"':
+			for i++; i < len(runes) && runes[i] != c; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			out = append(out, '?')
+		case unicode.IsDigit(c) && (len(out) == 0 || !isIdentRune(out[len(out)-1])):
+			for i+1 < len(runes) && (isIdentRune(runes[i+1]) || runes[i+1] == '.') {
+				i++
+			}
+			out = append(out, '?')
+		case unicode.IsSpace(c):
+			space()
+		default:
+			out = append(out, c)
+		}
+	}
+	s := strings.TrimSpace(string(out))
+	s = fingerprintValues.ReplaceAllString(s, "VALUES $1")
+	return fingerprintIN.ReplaceAllString(s, "IN (?+)")
+}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || c == '$' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// countPlaceholders counts the ? placeholders of query outside of string
+// literals, quoted identifiers and comments.
+func countPlaceholders(query string) int {
+	n := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '?':
+			n++
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(query) && query[i] != c; i++ {
+				if query[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		case c == '-' && i+1 < len(query) && query[i+1] == '-', c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			for i += 2; i+1 < len(query) && !(query[i] == '*' && query[i+1] == '/'); i++ {
+			}
+			i++
+		}
+	}
+	return n
+}
+
+// convertPlaceholders rewrites the ? placeholders of a mssql query to
+// @p1..@pN. Placeholders in string literals, quoted identifiers and comments
+// are left alone, and mixing ? with @pN is an error. Other drivers get query
+// unchanged.
+func convertPlaceholders(driver, query string) (string, error) {
+	if driver != "mssql" {
+		return query, nil
+	}
+	var b strings.Builder
+	marks, ordinals, last := 0, 0, 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '?':
+			marks++
+			b.WriteString(query[last:i])
+			fmt.Fprintf(&b, "@p%d", marks)
+			last = i + 1
+		case c == '@':
+			if j := ordinalEnd(query, i); j >= 0 {
+				ordinals++
+				i = j - 1
+			}
+		case c == '\'' || c == '"' || c == '[':
+			// T-SQL escapes by doubling the quote, which scans as two
+			// adjacent literals, so there is no backslash escape.
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			start := i
+			for i++; i < len(query) && query[i] != end; i++ {
+			}
+			if i >= len(query) {
+				return "", fmt.Errorf("unterminated %c at offset %d", c, start)
+			}
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			for i += 2; i+1 < len(query) && !(query[i] == '*' && query[i+1] == '/'); i++ {
+			}
+			i++
+		}
+	}
+	if marks > 0 && ordinals > 0 {
+		return "", fmt.Errorf("statement mixes %d ? placeholders with %d @p placeholders", marks, ordinals)
+	}
+	if last == 0 {
+		return query, nil
+	}
+	b.WriteString(query[last:])
+	return b.String(), nil
+}
+
+// ordinalEnd returns the end offset of the @pN placeholder starting at i,
+// or -1 if there is none.
+func ordinalEnd(query string, i int) int {
+	if i > 0 && (isIdentRune(rune(query[i-1])) || query[i-1] == '@') {
+		return -1
+	}
+	if i+2 >= len(query) || query[i+1] != 'p' && query[i+1] != 'P' {
+		return -1
+	}
+	j := i + 2
+	for j < len(query) && '0' <= query[j] && query[j] <= '9' {
+		j++
+	}
+	if j == i+2 || j < len(query) && isIdentRune(rune(query[j])) {
+		return -1
+	}
+	return j
+}
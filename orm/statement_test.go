@@ -0,0 +1,115 @@
+package orm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestSpanName(t *testing.T) {
+	cases := []struct {
+		sql, out string
+	}{
+		{"SELECT `id`, `name` FROM `users` WHERE id = ?", "SELECT users"},
+		{"  select count(*) from blogs b join users u on b.user_id = u.id", "SELECT blogs"},
+		{"/* batch */ INSERT INTO [dbo].[Order] (a) VALUES (?)", "INSERT dbo.Order"},
+		{"UPDATE users SET name = 'FROM x' WHERE id = ?", "UPDATE users"},
+		{"DELETE FROM users WHERE id = ?", "DELETE users"},
+		{"SELECT 1", "SELECT"},
+		{"SHOW TABLES", "SHOW"},
+		{"", "DB Query"},
+		{"'garbage", "DB Query"},
+	}
+	for i, c := range cases {
+		if out := spanName("DB Query", c.sql); out != c.out {
+			t.Errorf("#%d expected %q, got %q", i+1, c.out, out)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	cases := []struct {
+		sql, out string
+	}{
+		{"SELECT * FROM users\n\tWHERE id = 42  AND name = 'bob'", "SELECT * FROM users WHERE id = ? AND name = ?"},
+		{"select a from t1 where b in (1, 2, 3) -- trailing", "select a from t1 where b IN (?+)"},
+		{"SELECT a FROM t WHERE b IN (?,?,?)", "SELECT a FROM t WHERE b IN (?+)"},
+		{"INSERT INTO t (a, b) VALUES (?, ?), (?, ?), (?, ?)", "INSERT INTO t (a, b) VALUES (?, ?)"},
+		{"/* traceparent=00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 */ SELECT a FROM t", "SELECT a FROM t"},
+		{"/* x */ UPDATE t2 SET c = 'it\\'s', d = 0x1F WHERE e = -1.5", "UPDATE t2 SET c = ?, d = ? WHERE e = -?"},
+	}
+	for i, c := range cases {
+		if out := Fingerprint(c.sql); out != c.out {
+			t.Errorf("#%d expected %q, got %q", i+1, c.out, out)
+		}
+	}
+}
+
+func TestFingerprintCacheTraceComment(t *testing.T) {
+	Fingerprint("/* mockpfx-ids-traceid=1,mockpfx-ids-spanid=2 */ SELECT b FROM t")
+	if _, ok := _fingerprints.get("SELECT b FROM t"); !ok {
+		t.Errorf("expected fingerprint cached without the trace comment")
+	}
+}
+
+func TestCountPlaceholders(t *testing.T) {
+	cases := []struct {
+		sql string
+		n   int
+	}{
+		{"SELECT * FROM t WHERE a = ? AND b IN (?, ?)", 3},
+		{"SELECT '?', \"it\\\"s ?\", `a?` FROM t WHERE a = ?", 1},
+		{"SELECT a -- why?\nFROM t /* what? */ WHERE b = ? # how?", 1},
+		{"SELECT 1", 0},
+	}
+	for i, c := range cases {
+		if n := countPlaceholders(c.sql); n != c.n {
+			t.Errorf("#%d expected %d, got %d", i+1, c.n, n)
+		}
+	}
+}
+
+func TestConvertPlaceholders(t *testing.T) {
+	cases := []struct {
+		driver string
+		sql    string
+		expect string
+		err    bool
+	}{
+		{"mssql", "SELECT * FROM t WHERE a = ? AND b = '?' AND [c?] = ?", "SELECT * FROM t WHERE a = @p1 AND b = '?' AND [c?] = @p2", false},
+		{"mssql", "SELECT * FROM t WHERE a = @p1", "SELECT * FROM t WHERE a = @p1", false},
+		{"mssql", "SELECT * FROM t WHERE a = @p1 AND b = ?", "", true},
+		{"mssql", `SELECT * FROM t WHERE a = 'C:\' AND b = 'it''s ?' AND c = ?`, `SELECT * FROM t WHERE a = 'C:\' AND b = 'it''s ?' AND c = @p1`, false},
+		{"mssql", "SELECT * FROM t WHERE a = 'it''s ?", "", true},
+		{"mysql", "SET @p1 = ?; SELECT @p1", "SET @p1 = ?; SELECT @p1", false},
+		{"", "SELECT ?", "SELECT ?", false},
+	}
+	for i, c := range cases {
+		s, err := convertPlaceholders(c.driver, c.sql)
+		if c.err {
+			if err == nil {
+				t.Errorf("#%d expected error, got %q", i+1, s)
+			}
+			continue
+		}
+		if err != nil || s != c.expect {
+			t.Errorf("#%d expected %q, got %q (%v)", i+1, c.expect, s, err)
+		}
+	}
+}
+
+func TestTraceComment(t *testing.T) {
+	tracer := mocktracer.New()
+	span := tracer.StartSpan("DB Query")
+	span.SetBaggageItem("user", "secret")
+	sc := span.Context().(mocktracer.MockSpanContext)
+	expect := fmt.Sprintf("/* mockpfx-ids-traceid=%d,mockpfx-ids-spanid=%d */ ", sc.TraceID, sc.SpanID)
+	if out := traceComment(span); out != expect {
+		t.Errorf("expected %q, got %q", expect, out)
+	}
+	if out := traceComment(opentracing.NoopTracer{}.StartSpan("DB Query")); out != "" {
+		t.Errorf("expected no comment, got %q", out)
+	}
+}
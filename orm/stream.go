@@ -0,0 +1,139 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+)
+
+// Stream runs sql on db and sends every row, scanned into a T, on the
+// returned channel as it is read. T may be a struct or struct pointer,
+// scanned like ScanStruct, or any value Scan accepts for the first column.
+// Both channels are closed once the rows are exhausted, an error is sent or
+// ctx is done; the error channel receives at most one error. The consumer
+// must drain the rows channel or cancel ctx to release the connection.
+func Stream[T any](ctx context.Context, db DB, sql string, args ...interface{}) (<-chan T, <-chan error) {
+	out := make(chan T)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		defer close(errc)
+		if err := stream(ctx, db, out, sql, args); err != nil {
+			errc <- err
+		}
+	}()
+	return out, errc
+}
+
+func stream[T any](ctx context.Context, db DB, out chan<- T, query string, args []interface{}) error {
+	rows, err := queryDB(ctx, db, query, args)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	limit := dbRowLimit(ctx, db)
+	for rows.Next() {
+		if err := limit.add(); err != nil {
+			return err
+		}
+		var item T
+		if err := scanItem(rows, columns, &item); err != nil {
+			return err
+		}
+		select {
+		case out <- item:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return rows.Err()
+}
+
+// scanItem scans the current row into the T dest points to, field by field
+// if T is a struct, or pointer to one, with db tagged fields.
+func scanItem(rows *sql.Rows, columns []string, dest interface{}) error {
+	v := reflect.ValueOf(dest).Elem()
+	switch {
+	case isTaggedStruct(v.Type()):
+		return scanStruct(rows, columns, v)
+	case v.Kind() == reflect.Ptr && isTaggedStruct(v.Type().Elem()):
+		v.Set(reflect.New(v.Type().Elem()))
+		return scanStruct(rows, columns, v.Elem())
+	}
+	return scanFirst(rows, columns, dest)
+}
+
+// scanFirst scans the first column of the current row into dest,
+// discarding any other columns.
+func scanFirst(rows *sql.Rows, columns []string, dest interface{}) error {
+	targets := make([]interface{}, len(columns))
+	for i := range targets {
+		targets[i] = new(interface{})
+	}
+	if len(targets) > 0 {
+		targets[0] = dest
+	}
+	return rows.Scan(targets...)
+}
+
+// QueryColumn runs sql on db and returns the first column of every row
+// scanned into a T, e.g. the ids of SELECT id FROM ..., or an empty slice
+// if there are no rows.
+func QueryColumn[T any](ctx context.Context, db DB, sql string, args ...interface{}) ([]T, error) {
+	rows, err := queryDB(ctx, db, sql, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := []T{}
+	limit := dbRowLimit(ctx, db)
+	for rows.Next() {
+		if err := limit.add(); err != nil {
+			return nil, err
+		}
+		var item T
+		if err := scanFirst(rows, columns, &item); err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// queryDB runs query with ctx if db takes one.
+func queryDB(ctx context.Context, db DB, query string, args []interface{}) (*sql.Rows, error) {
+	if cdb, ok := db.(ContextDB); ok {
+		return cdb.QueryContext(ctx, query, args...)
+	}
+	return db.Query(query, args...)
+}
+
+// dbRowLimit is the row limit of db for ctx, see SetMaxRows.
+func dbRowLimit(ctx context.Context, db DB) *rowCounter {
+	if l, ok := db.(interface {
+		rowLimit(ctx context.Context) *rowCounter
+	}); ok {
+		return l.rowLimit(ctx)
+	}
+	return &rowCounter{max: ctxMaxRows(ctx, 0)}
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// isTaggedStruct reports whether t is a struct with db tagged fields that
+// is not itself a sql.Scanner, such as sql.NullString or NullTime.
+func isTaggedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(scannerType) &&
+		len(structColumns(t)) > 0
+}
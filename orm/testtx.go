@@ -0,0 +1,23 @@
+package orm
+
+// CleanupT is the part of *testing.T TestTx uses.
+type CleanupT interface {
+	Fatalf(format string, args ...interface{})
+	Cleanup(fn func())
+}
+
+// TestTx begins a transaction for a test that is always rolled back, even
+// by Close, when the test ends, leaving the database clean.
+func (store *DBStore) TestTx(t CleanupT) TX {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+	tx, err := store.BeginTx(store.context())
+	if err != nil {
+		t.Fatalf("begin test transaction: %v", err)
+		return nil
+	}
+	tx.(*DBTx).rollbackOnly = true
+	t.Cleanup(func() { tx.Close() })
+	return tx
+}
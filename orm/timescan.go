@@ -0,0 +1,68 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_timeLayoutsMu sync.RWMutex
+	_timeLayouts   []string
+
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// RegisterTimeLayouts adds layouts the struct scan helpers try, in order,
+// when a string column is scanned into a time.Time field, for legacy
+// columns storing datetimes as text. A field can list its own layouts,
+// separated by |, in a timelayout tag, which are tried first.
+func RegisterTimeLayouts(layouts ...string) {
+	_timeLayoutsMu.Lock()
+	defer _timeLayoutsMu.Unlock()
+	_timeLayouts = append(_timeLayouts, layouts...)
+}
+
+func fieldTimeLayouts(tag reflect.StructTag) []string {
+	if tag := tag.Get("timelayout"); tag != "" {
+		return strings.Split(tag, "|")
+	}
+	return nil
+}
+
+// timeConverter returns the converter of a time.Time or *time.Time field,
+// or false if there are no layouts to try.
+func timeConverter(typ reflect.Type, own []string) (ScanConverter, bool) {
+	if typ != timeType && typ != reflect.PtrTo(timeType) {
+		return nil, false
+	}
+	_timeLayoutsMu.RLock()
+	layouts := append(append([]string{}, own...), _timeLayouts...)
+	_timeLayoutsMu.RUnlock()
+	if len(layouts) == 0 {
+		return nil, false
+	}
+	return ScanConverterFunc(func(src interface{}, dest reflect.Value) error {
+		var s string
+		switch v := src.(type) {
+		case time.Time:
+			dest.Set(reflect.ValueOf(v))
+			return nil
+		case []byte:
+			s = string(v)
+		case string:
+			s = v
+		default:
+			return fmt.Errorf("can't convert %T to time.Time", src)
+		}
+		for _, layout := range layouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				dest.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("can't parse %q as time with layouts %q", s, layouts)
+	}), true
+}
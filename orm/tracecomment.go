@@ -0,0 +1,52 @@
+package orm
+
+import (
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// traceIDKeys are the text map keys carrying trace and span ids, rather
+// than baggage, of the W3C, jaeger, zipkin, basictracer and mock tracers.
+var traceIDKeys = []string{
+	"traceparent",
+	"uber-trace-id",
+	"x-b3-traceid", "x-b3-spanid",
+	"ot-tracer-traceid", "ot-tracer-spanid",
+	"mockpfx-ids-traceid", "mockpfx-ids-spanid",
+}
+
+// traceComment returns a /* traceparent=... */ comment carrying the trace
+// and span ids of span, for prepending to its statement so that server side
+// logs can be tied back to the trace. Tracers not propagating W3C
+// traceparent contribute their own id keys instead; baggage is never
+// included, and tracers injecting no ids, such as the noop tracer, get no
+// comment.
+func traceComment(span opentracing.Span) string {
+	carrier := opentracing.TextMapCarrier{}
+	if err := span.Tracer().Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+		return ""
+	}
+	ids := make(map[string]string, len(carrier))
+	for k, v := range carrier {
+		ids[strings.ToLower(k)] = v
+	}
+	var pairs []string
+	for _, k := range traceIDKeys {
+		v, ok := ids[k]
+		if !ok {
+			continue
+		}
+		if strings.Contains(v, "*/") {
+			return ""
+		}
+		if k == "traceparent" {
+			return "/* traceparent=" + v + " */ "
+		}
+		pairs = append(pairs, k+"="+v)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "/* " + strings.Join(pairs, ",") + " */ "
+}
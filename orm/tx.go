@@ -0,0 +1,89 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// BeginTxOptions begins a transaction with opts, e.g. a stronger isolation
+// level than the server default. A nil opts is BeginTx.
+func (store *DBStore) BeginTxOptions(ctx context.Context, opts *sql.TxOptions) (TX, error) {
+	if opts == nil || !opts.ReadOnly {
+		if err := store.checkWritable(); err != nil {
+			return nil, err
+		}
+	}
+	return store.beginTx(ctx, opts)
+}
+
+// WithTransaction runs fn in a transaction that is committed if fn returns
+// nil and rolled back if it fails or panics.
+func (store *DBStore) WithTransaction(ctx context.Context, fn func(TX) error) error {
+	return store.withTx(ctx, nil, fn)
+}
+
+// Serializable is WithTransaction at the SERIALIZABLE isolation level.
+func (store *DBStore) Serializable(ctx context.Context, fn func(TX) error) error {
+	return store.withTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, fn)
+}
+
+// RepeatableRead is WithTransaction at the REPEATABLE READ isolation level.
+func (store *DBStore) RepeatableRead(ctx context.Context, fn func(TX) error) error {
+	return store.withTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead}, fn)
+}
+
+func (store *DBStore) withTx(ctx context.Context, opts *sql.TxOptions, fn func(TX) error) (err error) {
+	tx, err := store.BeginTxOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.SetError(fmt.Errorf("panic: %v", p))
+			tx.Close()
+			panic(p)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		tx.SetError(err)
+		tx.Close()
+		return err
+	}
+	return tx.Close()
+}
+
+// BeginTxTimeout begins a transaction bounded by d: statements after the
+// deadline fail and Close rolls back.
+func (store *DBStore) BeginTxTimeout(ctx context.Context, d time.Duration) (TX, error) {
+	if ctx == nil {
+		ctx = store.context()
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	tx, err := store.BeginTx(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	tx.(*DBTx).cancel = cancel
+	return tx, nil
+}
+
+// OnCommit queues fn to run after Close commits, for side effects such as
+// publishing events that must not happen for a rolled back transaction.
+func (tx *DBTx) OnCommit(fn func()) {
+	tx.onCommit = append(tx.onCommit, fn)
+}
+
+// OnRollback queues fn to run after Close rolls back, or after a failed
+// commit.
+func (tx *DBTx) OnRollback(fn func()) {
+	tx.onRollback = append(tx.onRollback, fn)
+}
+
+func runAll(fns []func()) {
+	for _, fn := range fns {
+		fn()
+	}
+}
@@ -0,0 +1,28 @@
+package orm
+
+import "context"
+
+type txContextKey struct{}
+
+// ContextWithTx returns a copy of ctx carrying tx, so that functions deeper
+// in the call chain can join the same transaction.
+func ContextWithTx(ctx context.Context, tx TX) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+func TxFromContext(ctx context.Context) (TX, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(txContextKey{}).(TX)
+	return tx, ok
+}
+
+// DBFromContext returns the transaction carried by ctx, or db if there is
+// none.
+func DBFromContext(ctx context.Context, db DB) DB {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,58 @@
+package orm
+
+import (
+	"log"
+	"time"
+)
+
+// TxIdleMetrics is optionally implemented by a Metrics to receive, for
+// every transaction closed after at least one statement, how long it sat
+// idle between its last statement and Close.
+type TxIdleMetrics interface {
+	ObserveTxIdle(d time.Duration)
+}
+
+// SetTxIdleWarning logs a warning with the last statement of transactions
+// left idle for longer than d before Close, holding their locks while the
+// caller does other work. Zero disables the warning.
+func (store *DBStore) SetTxIdleWarning(d time.Duration) {
+	store.txIdle = d
+}
+
+// SetSlowTxLog logs transactions open for longer than d in total when they
+// close, with their statement count, catching lock holding time spent
+// between statements that SlowLog misses. Zero disables it.
+func (store *DBStore) SetSlowTxLog(d time.Duration) {
+	store.slowTx = d
+}
+
+func (tx *DBTx) touch(sql string) {
+	tx.stmts++
+	if tx.txIdle > 0 || tx.metrics != nil {
+		tx.lastStmt, tx.lastSQL = time.Now(), sql
+	}
+}
+
+func (tx *DBTx) checkIdle() {
+	if tx.lastStmt.IsZero() {
+		return
+	}
+	idle := time.Now().Sub(tx.lastStmt)
+	tx.lastStmt = time.Time{}
+	if m, ok := tx.metrics.(TxIdleMetrics); ok {
+		m.ObserveTxIdle(idle)
+	}
+	if tx.txIdle > 0 && idle > tx.txIdle {
+		log.Println("WARN: ", "transaction idle for", idle.String(), "before close; last statement:", tx.lastSQL)
+	}
+}
+
+func (tx *DBTx) checkSlow() {
+	if tx.slowTx <= 0 || tx.started.IsZero() {
+		return
+	}
+	if d := time.Now().Sub(tx.started); d > tx.slowTx {
+		log.Println("SLOW: ", "transaction", d.String(), "statements", tx.stmts)
+	}
+	tx.started = time.Time{}
+}
@@ -0,0 +1,93 @@
+package orm
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// txTracker records the open transactions of a store to hunt leaks: DBTx
+// values that were begun but never closed.
+type txTracker struct {
+	mu        sync.Mutex
+	open      map[*DBTx]*trackedTx
+	threshold int
+	maxAge    time.Duration
+	stop      chan struct{}
+	stopOnce  sync.Once
+	// owner is the store that started the tracker; its clones share it.
+	owner *DBStore
+}
+
+type trackedTx struct {
+	started time.Time
+	stack   []byte
+	warned  bool
+}
+
+// TrackTransactions logs a warning with the BeginTx stack when more than
+// threshold transactions are open at once, or when a transaction is still
+// open after maxAge. Zero disables either check; capturing stacks is
+// costly, so this is meant for debugging only.
+func (store *DBStore) TrackTransactions(threshold int, maxAge time.Duration) {
+	if t := store.txTracker; t != nil {
+		if t.owner == store {
+			t.close()
+		}
+		store.txTracker = nil
+	}
+	if threshold <= 0 && maxAge <= 0 {
+		return
+	}
+	t := &txTracker{
+		open:      map[*DBTx]*trackedTx{},
+		threshold: threshold,
+		maxAge:    maxAge,
+		stop:      make(chan struct{}),
+		owner:     store,
+	}
+	if maxAge > 0 {
+		go t.watch()
+	}
+	store.txTracker = t
+}
+
+func (t *txTracker) close() {
+	t.stopOnce.Do(func() { close(t.stop) })
+}
+
+func (t *txTracker) add(tx *DBTx) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.open[tx] = &trackedTx{started: time.Now(), stack: debug.Stack()}
+	if t.threshold > 0 && len(t.open) > t.threshold {
+		log.Printf("WARN: %d open transactions, exceeding %d; begun at:\n%s", len(t.open), t.threshold, t.open[tx].stack)
+	}
+}
+
+func (t *txTracker) remove(tx *DBTx) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.open, tx)
+}
+
+func (t *txTracker) watch() {
+	ticker := time.NewTicker(t.maxAge / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.stop:
+			return
+		case now := <-ticker.C:
+			t.mu.Lock()
+			for _, tt := range t.open {
+				if age := now.Sub(tt.started); !tt.warned && age > t.maxAge {
+					tt.warned = true
+					log.Printf("WARN: transaction open for %s; begun at:\n%s", age, tt.stack)
+				}
+			}
+			t.mu.Unlock()
+		}
+	}
+}
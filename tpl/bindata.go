@@ -150,7 +150,7 @@ func tplConfMongoGogo() (*asset, error) {
 	return a, nil
 }
 
-var _tplConfMssqlGogo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x92\xc1\x6e\xdb\x30\x0c\x86\xcf\xd2\x53\x70\x3e\xd9\x41\xeb\xec\x05\xb2\xc3\x1a\x60\x1d\xd0\xb4\x1d\xb2\x9d\x0b\x45\xa6\x3d\x61\x91\xe8\x51\xf2\xda\xce\xd0\xbb\x0f\xb2\xd3\xc2\x76\xd6\xcd\x80\x0f\x22\xf9\xff\x24\x3f\xa9\xef\x2b\xac\x8d\x43\xc8\x34\xb9\xba\xb4\xde\xff\x3c\x66\x31\xb6\x4a\xff\x50\x0d\x42\xdf\x97\x9f\xe8\x7e\x3c\xc4\x28\x8d\x6d\x89\x03\xe4\x52\x64\xfe\xd9\xe9\x4c\x8a\x2c\x18\x8b\x99\x94\x22\x6b\x4c\xf8\xde\x1d\x4a\x4d\x76\xad\xba\x40\x97\x2d\x53\xc3\xca\xae\xab\xc3\x25\xb1\x5d\x13\xdb\x4c\x16\x52\xfe\x52\x9c\xf4\x0f\x43\xa7\x07\x1f\x88\x11\x56\xc4\xb6\xdc\x7e\xdc\xa7\xc3\x6b\x4a\xd7\x0d\x00\xec\xfc\xfe\xcb\xcd\x15\xb9\xda\x34\xaf\x19\x72\x1a\x01\xd2\x00\xe5\x9d\xd3\x98\x5c\xc3\x73\x8b\xd3\x5a\xf0\x81\x3b\x1d\xa0\x97\xe2\x9a\x7c\x80\xc9\xe7\x03\x1b\xd7\x48\x71\x9f\x36\x99\x7c\xc6\x05\x29\xbe\x79\xe4\x5b\x65\xf1\xac\x58\x79\xff\x48\x5c\x2d\xe3\x5b\x15\xd4\x41\xf9\xf3\x7a\xa2\xe3\xde\xfc\xc6\x99\xf9\x15\x39\xb7\x53\x4f\x37\xa6\xc6\xaf\xc6\x22\x24\x74\xe5\xb6\x63\x15\x0c\x39\x19\xa5\xac\x3b\xa7\xc7\x2d\xf6\x18\xba\x36\xd7\x35\xac\x26\x4b\x15\x69\x9d\x09\x9d\x0d\xac\x74\x3d\xd7\xe5\xc5\x0c\x66\x12\x24\xe0\xc8\xc3\x4f\x3c\x63\x58\x6e\x29\x4f\xd2\x7c\x30\x9e\x5d\xc9\xc5\x20\xd9\x40\xf2\xba\xc5\xc7\x93\x5d\x9e\x8d\xef\xe3\x42\x0a\x31\x19\xa4\x4c\x88\x97\xb1\x84\x77\x19\x7b\xa1\xb5\x8c\xbf\x50\x3f\xf3\x38\x51\x2f\xa4\x10\xa6\x1e\x46\x7a\xb7\x01\x67\x8e\xc3\xbc\xa2\x55\xce\xe8\x1c\x99\x53\x3e\x2e\x36\x28\xf7\x18\x26\xc0\x13\xeb\x7c\x00\x7e\x4d\x1d\x9f\x0c\x27\xad\x96\x77\xf3\x01\xde\x8f\x4d\xfe\xe7\xf9\xb6\xc7\x5b\x53\xed\xd4\xd3\xe7\xea\x88\xa9\xda\xe7\x33\x62\xe3\x9b\x29\xfe\xae\xb9\x6b\xd1\xfd\x4b\x13\x0b\x29\x18\x43\xc7\x0e\xa6\x6a\x19\x65\xdf\xa3\xab\x62\xfc\x13\x00\x00\xff\xff\x51\x56\x61\x5c\xeb\x03\x00\x00")
+var _tplConfMssqlGogo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x94\xcb\x6e\xdb\x3c\x10\x85\xd7\xe2\x53\xcc\xaf\xc5\x0f\x39\x48\x94\xae\x0d\xa4\x40\x13\x17\x4d\x81\xdc\x0a\xa7\xeb\x80\xa6\x47\x2a\x51\x8b\xa3\x0e\xa9\x5c\x2a\xe8\xdd\x8b\xa1\x25\x43\xb6\x63\x87\x40\x02\x90\xfc\xce\x19\x92\x73\xe4\xb6\x5d\x62\x61\x1d\x42\x6a\xc8\x15\x79\xe5\xfd\x9f\x55\xda\x75\xb5\x36\xbf\x75\x89\xd0\xb6\xf9\x37\x7a\x58\x4f\xba\x4e\xd9\xaa\x26\x0e\x90\xa9\x24\xf5\x6f\xce\xa4\x2a\x49\x83\xad\x30\x55\x2a\x49\x4b\x1b\x7e\x35\x8b\xdc\x50\x75\xae\x9b\x40\x67\x35\x53\xc9\xba\x3a\x5f\x2e\xce\x88\xab\x73\xe2\x2a\x55\x13\xa5\x9e\x35\x8b\xfe\x29\x56\x7a\xf2\x81\x18\xe1\x84\xb8\xca\x67\x97\x73\x99\x6c\xb6\x4c\x51\x02\xc0\xad\x9f\xff\xb8\xb9\x22\x57\xd8\x72\xb3\x43\xce\x20\x80\x1c\x20\xbf\x77\x06\xc5\x35\xbc\xd5\x38\x66\xc1\x07\x6e\x4c\x80\x56\x25\xd7\xe4\x03\x8c\x86\x0f\x6c\x5d\xa9\x92\x07\xb9\xc9\x68\x58\x17\x54\xf2\xd3\x23\xdf\xe9\x0a\xf7\x60\xed\xfd\x0b\xf1\x72\x77\x7d\xa6\x83\x5e\x68\xbf\xcf\x13\xad\xe6\xf6\xef\x66\x3d\x9a\x5f\x91\x73\xb7\xfa\xf5\xc6\x16\xf8\x68\x2b\x04\x79\xba\x7c\xd6\xb0\x0e\x96\x9c\x52\xc9\x57\x67\xf8\xad\xde\x3a\xd4\xd8\xf3\x91\x1b\x1f\xe6\xc8\xcf\xc8\x57\xc8\xc1\x16\xd6\xe8\x80\xb0\x20\x5a\xa9\xe4\x4b\x5d\x8f\xcf\xbd\xab\x95\xd2\x68\xa4\x8e\x54\xa6\xa6\x2f\xb2\x7d\x82\x4e\xa9\xa2\x71\x66\xfd\x8e\x73\x0c\x4d\x9d\x99\x02\x4e\x46\xcf\x3a\x81\x76\xd3\x05\xe9\xcf\x05\x9c\x98\x62\x5b\x97\x4d\xb6\xda\x29\x02\x69\x39\x72\xfc\x23\xde\xea\x62\x3e\xa3\x4c\xa4\x59\x34\x1e\x76\x62\x28\x4e\x05\x87\x0b\x10\xaf\x3b\x7c\xe9\xed\xd6\xdd\xcd\xfe\x97\xd5\xd9\xe5\x7a\x26\xca\x64\xc6\xf6\x19\x79\x3a\xdc\x7b\x33\xd2\x68\x99\x9e\x0a\x23\x49\xd8\x27\x00\xfa\xb2\xa6\x28\x73\x41\x22\x2b\xe9\xf8\x80\x15\x24\xb2\x43\x08\xa6\x87\xd9\x01\x89\xfc\x10\xb2\x23\xfc\x80\x44\x7e\x08\xdf\x11\x7e\x40\x22\xdf\xe7\x68\x0a\x87\xf9\x1e\x89\xf8\xfb\xb9\x9a\x8e\xf1\xf7\x91\xa8\xee\x83\x77\xac\x58\x8f\x44\x7c\x2f\x88\xd3\x3d\x7c\x0f\x11\x61\x37\x51\x49\x62\x8b\x18\x8a\xff\x2e\xc0\xd9\x55\x4c\x4c\x52\x6b\x67\x4d\x86\xcc\xb2\xdf\xed\x64\x28\x9f\x63\x18\x7d\x74\x92\xf6\x4c\xfe\xe5\xd7\xd4\x70\x6f\xb8\x53\x78\xfc\x7d\x7e\x86\x4f\xeb\x22\x1f\x79\x1e\xf6\x38\x74\xaa\x5b\xfd\xfa\x7d\xb9\x42\x31\xf2\x63\xf9\xf0\xbb\x31\x79\x5f\x73\x5f\xa3\x3b\xa6\x91\x57\x62\x0c\x0d\xbb\xe1\x5e\xf1\x1d\x54\xa7\xda\x16\xdd\xb2\xeb\xfe\x0d\x00\xb0\x96\xd1\x75\xef\x05\x00\x00")
 
 func tplConfMssqlGogoBytes() ([]byte, error) {
 	return bindataRead(
@@ -170,7 +170,7 @@ func tplConfMssqlGogo() (*asset, error) {
 	return a, nil
 }
 
-var _tplConfMysqlGogo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x92\xc1\x6e\x9c\x30\x10\x86\xcf\xf6\x53\x4c\x38\xc1\x2a\x61\xfb\x02\xdb\x43\xb3\x52\x53\x29\x9b\xa4\xa2\x3d\x47\x5e\x33\x50\xab\x6b\x0f\x1d\x9b\x26\x5b\xc4\xbb\x57\x86\x4d\x0a\x6c\xd3\x22\x71\xf0\xf8\xff\x7f\xcf\x7c\x76\xd7\x95\x58\x19\x87\x90\x68\x72\x55\x6e\x8f\xfe\xc7\x21\xe9\xfb\x46\xe9\xef\xaa\x46\xe8\xba\xfc\x23\x3d\x8c\x8b\xbe\x97\xeb\xf5\x05\xfc\xd1\x49\x63\x1b\xe2\x00\xa9\x14\x89\x3f\x3a\x9d\x48\x91\x04\x63\x31\x91\x52\x24\xb5\x09\xdf\xda\x7d\xae\xc9\xae\x55\x1b\xe8\xaa\x61\xaa\x59\xd9\x75\xb9\xbf\x22\xb6\x6b\x62\x9b\xc8\x4c\xca\x9f\x8a\xa3\xff\x71\x08\x7c\xf4\x81\x18\x61\x45\x6c\xf3\xed\x87\x22\x2e\x5e\xb7\x74\x55\x03\xc0\xee\x58\x7c\xbe\xbd\x26\x57\x99\xfa\x75\x87\x9c\x46\x80\xd8\x40\x7e\xef\x34\xc6\xd4\x70\x6c\x70\xaa\x05\x1f\xb8\xd5\x01\x3a\x29\x6e\xc8\x07\x98\x7c\x3e\xb0\x71\xb5\x14\x0f\x71\x92\xc9\x67\x5c\x90\xe2\xab\x47\xbe\x53\x16\xcf\xc4\xca\xfb\x27\xe2\x72\x59\xdf\xaa\xa0\xf6\xca\x9f\xeb\x89\x0e\x85\xf9\x85\xb3\xf0\x6b\x72\x6e\xa7\x9e\x6f\x4d\x85\x5f\x8c\x45\x88\xe8\xf2\x6d\xcb\x2a\x18\x72\xb2\x97\xb2\x6a\x9d\x1e\xa7\x28\x30\xb4\x4d\xaa\x2b\x58\x4d\x86\xca\xe2\x38\x13\x3a\x1b\x58\xe9\x6a\xee\x4b\x33\x18\x59\x46\x69\x44\x8d\x3c\xfc\xc4\x33\x7a\xf9\x96\xd2\x68\x4a\x87\xc8\xd9\x65\x5c\x0e\x96\xcd\x10\x73\x87\x4f\xa7\x5b\x49\x93\xf1\xa1\x5c\x4a\x21\x26\x2d\xe4\x11\xee\xb2\x16\xc1\x2e\x6b\x2f\x9c\x96\xf5\x17\xde\x67\x19\x27\xde\x99\x14\xc2\x54\x43\x4b\x17\x1b\x70\xe6\x30\xf4\x2b\x1a\xe5\x8c\x4e\x91\x39\xee\xf7\x8b\x09\xf2\x02\xc3\x04\x75\xa4\x9c\x0e\xa8\x6f\xa8\xe5\x53\xe0\xe4\xa8\xe5\xad\xbc\x87\x77\xe3\x21\xff\xcb\x7c\x3b\xe3\xad\xae\x76\xea\xf9\x53\x79\xc0\xa8\xf6\xe9\x8c\xd8\xf8\x5a\xb2\xbf\x7b\xee\x1b\x74\xff\xf2\xf4\x99\x14\x8c\xa1\x65\x07\x53\x77\x7c\x19\x5d\x87\xae\xec\x7b\xf9\x3b\x00\x00\xff\xff\x04\xa4\x34\x19\xf6\x03\x00\x00")
+var _tplConfMysqlGogo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x52\xe1\x6b\xdb\x3e\x10\xfd\x6c\xfd\x15\x57\x7f\xf8\xe1\x84\xd6\xf9\x7d\x0e\x64\xb0\xd6\xb0\x0e\x9a\xb6\x23\xdb\xa7\x31\x8a\xa2\x9c\x3d\xb1\x48\xf2\x4e\x72\xdb\xcc\xf8\x7f\x1f\x27\xc7\x99\x9b\xb4\x9b\x21\x01\xe9\xbd\x7b\xa7\x7b\xef\xda\x76\x83\xa5\xb6\x08\xa9\x72\xb6\xcc\xcd\xce\xff\xdc\xa6\x5d\x57\x4b\xf5\x43\x56\x08\x6d\x9b\x7f\x70\xf7\xfd\xa1\xeb\xc4\x6c\x76\x06\x7f\x78\x42\x9b\xda\x51\x80\x4c\x24\xa9\xdf\x59\x95\x8a\x24\x0d\xda\x60\x2a\x44\x92\x56\x3a\x7c\x6f\xd6\xb9\x72\x66\x26\x9b\xe0\x2e\x6a\x72\x15\x49\x33\xdb\xac\x2f\x1c\x99\x99\x23\x93\x8a\x89\x10\x8f\x92\xb8\xfe\x21\x36\x7e\xf0\xc1\x11\xc2\xd4\x91\xc9\x8b\xcb\x15\x1f\x0e\x90\x2a\x2b\x00\x58\xee\x56\x9f\x6e\xae\x9c\x2d\x75\x75\x40\x9c\x55\x08\xc0\x0f\xc8\xef\xac\x42\x56\x0d\xbb\x1a\xc7\x5c\xf0\x81\x1a\x15\xa0\x15\xc9\xb5\xf3\x01\x46\x9f\x0f\xa4\x6d\x25\x92\x7b\x9e\x64\xf4\x69\x1b\x44\xf2\xc5\x23\xdd\x4a\x83\x27\x64\xe9\xfd\x93\xa3\xcd\xf1\x7d\x21\x83\x5c\x4b\x7f\xca\x77\x6e\xbb\xd2\xbf\x0e\xf7\x51\xfc\xca\x59\xbb\x94\xcf\x37\xba\xc4\xcf\xda\x20\xb0\x75\x79\xd1\x90\x0c\xda\xd9\x1e\x46\x15\xde\x87\x40\x9e\x8b\x8c\xac\xbf\xf6\x7d\xbe\xed\x65\x3b\x21\xca\xc6\xaa\x7e\xd0\x15\x86\xa6\xce\x54\x09\xd3\xd1\xdc\x13\x68\x0f\x36\xb1\x81\x0b\x98\xaa\xf2\x65\x5d\x36\x81\xde\x6e\xa6\x72\x1a\x48\xf1\xe7\xe8\x85\xc1\x79\xe1\x32\x6e\x96\x45\xc9\x01\x89\x79\x9d\x33\x1d\x16\x51\xe6\x16\x9f\xf6\xc1\xf5\xc6\x67\xff\xf1\x6d\x71\xd9\x9f\xb8\x32\x29\x48\x3f\x22\xcd\xf7\x4e\xa4\x51\x28\x3d\x67\x84\xa3\x19\xee\x01\xf6\x2d\x54\x59\xe5\x0c\x44\x06\x87\xf4\x2a\x83\x81\xc8\x18\x12\x98\x1f\x33\x06\x20\xb2\x86\x5c\x4f\x58\x03\x10\x59\x43\xca\x27\xac\x01\x88\xac\x71\x4e\xf3\x31\x6b\x0c\x30\xb3\x9b\x88\x24\xd1\x65\x74\xeb\x6c\x01\x56\x6f\xa3\x95\x49\x2d\xad\x56\x19\x12\x31\xde\x1d\x99\x9b\xaf\x30\x8c\x16\x85\x77\x24\xe3\xbf\xfc\xda\x35\xb4\x17\x3c\xea\x39\xde\xa9\x77\xf0\x7f\xdf\xe4\x5f\x9a\x6f\x6b\xbc\xf5\xaa\xa5\x7c\xfe\xb8\xd9\x22\x0b\xf9\x71\xf9\xb0\xeb\x93\xd7\x6b\xee\x6a\xb4\x7f\xab\x61\x97\x08\x43\x43\x76\x98\x2b\xfa\xc0\x4b\xdb\xb6\x68\x37\x5d\x27\x7e\x0f\x00\xef\x55\x57\x92\xb4\x04\x00\x00")
 
 func tplConfMysqlGogoBytes() ([]byte, error) {
 	return bindataRead(